@@ -1,88 +1,395 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/config"
 	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+	"github.com/Jersonmade/pr-reviewer-service/internal/idempotency"
+	"github.com/Jersonmade/pr-reviewer-service/internal/limits"
+	"github.com/Jersonmade/pr-reviewer-service/internal/logging"
+	"github.com/Jersonmade/pr-reviewer-service/internal/metrics"
 	"github.com/Jersonmade/pr-reviewer-service/internal/services"
 	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"github.com/Jersonmade/pr-reviewer-service/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
-	dbHost := getEnv("DB_HOST", "postgres_db")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbUser := getEnv("DB_USER", "postgres")
-	dbPassword := getEnv("DB_PASSWORD", "postgres")
-	dbName := getEnv("DB_NAME", "pr_reviewer_service")
+	flag.Usage = usage
 
-	connString := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPassword, dbName,
-	)
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.LookupEnv)
 
-	store, err := storage.NewPostgresStorage(connString)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// The standard log package, still used for the fatal errors below, goes
+	// through the same handler.
+	slog.SetDefault(logger)
+
+	switch flag.Arg(0) {
+	case "":
+	case "migrate":
+		if err := runMigrate(cfg, flag.Args()[1:], logger); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+
+		return
+	case "tokens":
+		if err := runTokens(cfg, flag.Args()[1:], logger); err != nil {
+			log.Fatalf("tokens: %v", err)
+		}
+
+		return
+	case "config":
+		if err := runConfig(cfg, flag.Args()[1:]); err != nil {
+			log.Fatalf("config: %v", err)
+		}
+
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(cfg, logger); err != nil {
+		logger.Error("server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg *config.Config, logger *slog.Logger) error {
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.Endpoint, os.Stdout)
 
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return err
+	}
+
+	// Runs last, so spans of the final requests and of closing the pool
+	// are flushed too.
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("failed to flush trace spans", "error", err)
+		}
+	}()
+
+	store, err := newStore(cfg, logger)
+
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
 	defer func() {
 		if err := store.Close(); err != nil {
-			log.Printf("failed to close store: %v", err)
+			logger.Error("failed to close store", "error", err)
 		}
 	}()
 
-	log.Println("Successfully connected to PostgreSQL")
+	options := cfg.ServiceOptions()
 
 	userService := services.NewUserService(store)
-	teamService := services.NewTeamService(store)
-	prService := services.NewPRService(store, userService)
+	teamService := services.NewTeamService(store, options, logger)
+	prService := services.NewPRService(store, userService, options, logger)
 	statsService := services.NewStatsService(store)
+	tokenService := services.NewTokenService(store, logger)
+
+	userHandler := handlers.NewUserHandler(userService, prService, logger)
+	teamHandler := handlers.NewTeamHandler(teamService, prService, logger)
+	prHandler := handlers.NewPRHandler(prService, logger)
+	analyticsHandler := handlers.NewAnalyticsHandler(statsService, logger)
+	tokenHandler := handlers.NewTokenHandler(tokenService, logger)
+	healthHandler := handlers.NewHealthHandler(cfg.HTTP.ReadinessTimeout, logger)
+	authenticator := handlers.NewAuthenticator(tokenService, cfg.Auth.Enabled, cfg.Auth.ActorHeader, logger)
+
+	if !cfg.Auth.Enabled {
+		logger.Warn("authentication is disabled, every caller may use every route")
+	}
+
+	if cfg.Auth.Enabled && cfg.Storage == "memory" {
+		if err := issueBootstrapToken(context.Background(), tokenService, logger); err != nil {
+			return fmt.Errorf("failed to issue the bootstrap token: %w", err)
+		}
+	}
+
+	if pg, ok := store.(*storage.PostgresStorage); ok {
+		if err := addPostgresChecks(healthHandler, pg, logger); err != nil {
+			return fmt.Errorf("failed to set up readiness checks: %w", err)
+		}
+
+		if err := metrics.RegisterDB(pg.DB()); err != nil {
+			return fmt.Errorf("failed to register database metrics: %w", err)
+		}
+	}
+
+	// Every API route declares the permission it requires. Which team or
+	// pull request the caller may act on is checked by the services.
+	routes := []struct {
+		pattern string
+		perm    auth.Permission
+		handler http.HandlerFunc
+	}{
+		{"/team/add", auth.PermAdmin, teamHandler.AddTeam},
+		{"/team/get", auth.PermRead, teamHandler.GetTeam},
+		{"/team/settings", auth.PermTeamManage, teamHandler.UpdateTeamSettings},
+		{"/team/deactivateUsers", auth.PermTeamManage, teamHandler.DeactivateUsers},
+		{"/team/addMembers", auth.PermTeamManage, teamHandler.AddMembers},
+		{"/team/removeMembers", auth.PermTeamManage, teamHandler.RemoveMembers},
+		{"/team/transferUser", auth.PermAdmin, teamHandler.TransferUser},
+		{"/team/rename", auth.PermTeamManage, teamHandler.RenameTeam},
+		{"/team/delete", auth.PermAdmin, teamHandler.DeleteTeam},
+
+		{"/users/setIsActive", auth.PermTeamManage, userHandler.SetUserActive},
+		{"/users/getReview", auth.PermRead, userHandler.GetUserReviews},
+
+		{"/pullRequest/create", auth.PermPRWrite, prHandler.CreatePR},
+		{"/pullRequest/merge", auth.PermPRMerge, prHandler.MergePR},
+		{"/pullRequest/reassign", auth.PermPRWrite, prHandler.ReassignReviewer},
+		{"/pullRequest/review", auth.PermPRWrite, prHandler.SubmitReview},
+		{"/pullRequest/close", auth.PermPRWrite, prHandler.ClosePR},
+		{"/pullRequest/reopen", auth.PermPRWrite, prHandler.ReopenPR},
+		{"/pullRequest/ready", auth.PermPRWrite, prHandler.MarkReady},
+		{"/pullRequest/history", auth.PermRead, prHandler.GetPRHistory},
 
-	userHandler := handlers.NewUserHandler(userService, prService)
-	teamHandler := handlers.NewTeamHandler(teamService)
-	prHandler := handlers.NewPRHandler(prService)
-	analyticsHandler := handlers.NewAnalyticsHandler(statsService)
+		{"/stats/review_assignments", auth.PermRead, analyticsHandler.GetReviewAssignmentsStats},
+
+		{"/tokens/create", auth.PermAdmin, tokenHandler.CreateToken},
+		{"/tokens/list", auth.PermAdmin, tokenHandler.ListTokens},
+		{"/tokens/revoke", auth.PermAdmin, tokenHandler.RevokeToken},
+	}
 
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/team/add", teamHandler.AddTeam)
-	mux.HandleFunc("/team/get", teamHandler.GetTeam)
+	for _, route := range routes {
+		mux.Handle(route.pattern, authenticator.Require(route.perm, route.handler))
+	}
+
+	// The probes and metrics stay public for the orchestrator and scraper.
+	mux.HandleFunc("/livez", healthHandler.Livez)
+	mux.HandleFunc("/readyz", healthHandler.Readyz)
+	// Kept for existing clients; same as /readyz.
+	mux.HandleFunc("/health", healthHandler.Readyz)
+
+	mux.Handle("/metrics", promhttp.Handler())
+
+	public := []string{"/livez", "/readyz", "/health", "/metrics"}
+
+	// Built inside out: the trace span is started first so that log lines
+	// can carry its ID, then the request gets its ID, then metrics, so that
+	// rejected requests are counted too, then the caller is authenticated so
+	// that the rate limit is per token, then the body cap. Retries are
+	// replayed last, so that they still count against the limit.
+	var handler http.Handler = mux
 
-	mux.HandleFunc("/users/setIsActive", userHandler.SetUserActive)
-	mux.HandleFunc("/users/getReview", userHandler.GetUserReviews)
+	if cfg.Idempotency.Enabled {
+		handler = idempotency.Middleware(newIdempotencyStore(cfg, store, logger), mux, handler, logger)
+	}
 
-	mux.HandleFunc("/pullRequest/create", prHandler.CreatePR)
-	mux.HandleFunc("/pullRequest/merge", prHandler.MergePR)
-	mux.HandleFunc("/pullRequest/reassign", prHandler.ReassignReviewer)
+	handler = limits.MaxBody(int64(cfg.HTTP.MaxBodyBytes), handler, logger)
 
-	mux.HandleFunc("/stats/review_assignments", analyticsHandler.GetReviewAssignmentsStats)
+	if cfg.RateLimit.Enabled {
+		limiter, err := newLimiter(cfg, store, logger)
 
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, err := w.Write([]byte(`{"status":"ok"}`))
 		if err != nil {
-			log.Printf("failed to write response: %v", err)
+			return err
 		}
-	})
 
-	port := getEnv("PORT", "8080")
+		handler = limiter.Middleware(mux, handler, public...)
+	}
+
+	handler = authenticator.Middleware(handler)
 
-	log.Printf("Server starting on :%s...\n", port)
+	handler = metrics.Middleware(mux, handler)
+	handler = logging.Middleware(logger, mux, handler)
+	handler = tracing.Middleware(mux, handler, public...)
 
-	if err := http.ListenAndServe(":"+port, mux); err != nil {
-		log.Printf("Server failed: %v", err)
-		return
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.HTTP.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
 	}
+
+	return serve(server, healthHandler, cfg.HTTP, logger)
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// serve runs the server until it fails or SIGINT/SIGTERM arrives. On a
+// signal /readyz starts reporting "draining"; after DrainDelay, to let load
+// balancers notice, the listener is closed and in-flight requests get up to
+// ShutdownTimeout to finish. serve returns only once they did or were cut
+// off, so the caller can close the database pool afterwards.
+func serve(server *http.Server, health *handlers.HealthHandler, cfg config.HTTPConfig, logger *slog.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		logger.Info("server starting", "addr", server.Addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	// A second signal kills the process right away.
+	stop()
+
+	logger.Info("shutdown requested, draining connections", "timeout", (cfg.DrainDelay + cfg.ShutdownTimeout).String())
+	health.SetDraining()
+	time.Sleep(cfg.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("graceful shutdown did not finish, closing remaining connections", "error", err)
+
+		if err := server.Close(); err != nil {
+			return err
+		}
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	logger.Info("server stopped")
+
+	return nil
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+
+	fmt.Fprintf(out, "Usage:\n")
+	fmt.Fprintf(out, "  %s [flags]                           run the HTTP server\n", os.Args[0])
+	fmt.Fprintf(out, "  %s [flags] migrate status|up|down    show, apply or revert schema migrations\n", os.Args[0])
+	fmt.Fprintf(out, "  %s [flags] migrate baseline VERSION  mark migrations up to VERSION as applied\n", os.Args[0])
+	fmt.Fprintf(out, "  %s [flags] tokens create -name NAME -role ROLE [-team TEAM] [-user USER_ID]  issue an API token\n", os.Args[0])
+	fmt.Fprintf(out, "  %s [flags] tokens list|revoke ID     list or revoke API tokens\n", os.Args[0])
+	fmt.Fprintf(out, "  %s [flags] config print              print the effective configuration, secrets redacted\n", os.Args[0])
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func newStore(cfg *config.Config, logger *slog.Logger) (storage.Store, error) {
+	switch cfg.Storage {
+	case "memory":
+		logger.Warn("using in-memory storage, data will not survive a restart")
+		return storage.NewMemoryStorage(), nil
+	case "postgres":
+		store, err := newPostgresStore(cfg.Database, logger)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Database.MigrateOnStart {
+			if err := migrateUp(store, logger); err != nil {
+				_ = store.Close()
+				return nil, err
+			}
+		}
+
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage)
+	}
+}
+
+// newLimiter keeps the buckets in process unless they are to be shared
+// through Postgres.
+func newLimiter(cfg *config.Config, store storage.Store, logger *slog.Logger) (*limits.Limiter, error) {
+	routes, err := limits.ParseRouteQuotas(cfg.RateLimit.Routes)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate_limit.routes: %w", err)
+	}
+
+	var buckets limits.Store = limits.NewMemoryStore()
+
+	if cfg.RateLimit.Backend == "postgres" {
+		pg, ok := store.(*storage.PostgresStorage)
+
+		if !ok {
+			return nil, fmt.Errorf("rate_limit.backend postgres needs postgres storage")
+		}
+
+		buckets = limits.NewPostgresStore(pg.DB(), logger)
 	}
-	return defaultValue
+
+	quota := limits.Quota{PerMinute: cfg.RateLimit.PerMinute, Burst: cfg.RateLimit.Burst}
+
+	return limits.NewLimiter(buckets, quota, routes, cfg.RateLimit.ClientIPHeader, logger), nil
+}
+
+// newIdempotencyStore keeps the responses next to the data they describe.
+func newIdempotencyStore(cfg *config.Config, store storage.Store, logger *slog.Logger) idempotency.Store {
+	if pg, ok := store.(*storage.PostgresStorage); ok {
+		return idempotency.NewPostgresStore(pg.DB(), cfg.Idempotency.TTL, logger)
+	}
+
+	return idempotency.NewMemoryStore(cfg.Idempotency.TTL)
+}
+
+func newPostgresStore(db config.DatabaseConfig, logger *slog.Logger) (*storage.PostgresStorage, error) {
+	store, err := storage.NewPostgresStorage(db.ConnString(), storage.PoolOptions{
+		MaxOpenConns:    db.MaxOpenConns,
+		MaxIdleConns:    db.MaxIdleConns,
+		ConnMaxLifetime: db.ConnMaxLifetime,
+		ConnMaxIdleTime: db.ConnMaxIdleTime,
+	}, logger)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	logger.Info("connected to PostgreSQL")
+
+	return store, nil
+}
+
+// runConfig implements the config subcommand.
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		flag.Usage()
+		return fmt.Errorf("usage: config print")
+	}
+
+	out, err := cfg.Redacted().YAML()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(out)
+
+	return err
 }
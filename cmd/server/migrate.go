@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/config"
+	"github.com/Jersonmade/pr-reviewer-service/internal/migrate"
+	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"github.com/Jersonmade/pr-reviewer-service/migrations"
+)
+
+// runMigrate implements the migrate subcommand. It always works on
+// PostgreSQL, whatever -storage says.
+func runMigrate(cfg *config.Config, args []string, logger *slog.Logger) error {
+	if len(args) == 0 {
+		flag.Usage()
+		return fmt.Errorf("missing migrate command")
+	}
+
+	store, err := newPostgresStore(cfg.Database, logger)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.Error("failed to close store", "error", err)
+		}
+	}()
+
+	migrator, err := migrate.New(store.DB(), migrations.Files, logger)
+
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+
+		return w.Flush()
+	case "up":
+		return migrateUp(store, logger)
+	case "down":
+		reverted, err := migrator.Down(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if reverted == nil {
+			logger.Info("no migrations to revert")
+			return nil
+		}
+
+		logger.Info("reverted migration", "version", reverted.Version, "name", reverted.Name)
+
+		return nil
+	case "baseline":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: migrate baseline VERSION")
+		}
+
+		version, err := strconv.Atoi(args[1])
+
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+
+		if err := migrator.Baseline(ctx, version); err != nil {
+			return err
+		}
+
+		logger.Info("marked migrations as applied", "version", version)
+
+		return nil
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+func migrateUp(store *storage.PostgresStorage, logger *slog.Logger) error {
+	migrator, err := migrate.New(store.DB(), migrations.Files, logger)
+
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(context.Background())
+
+	for _, m := range applied {
+		logger.Info("applied migration", "version", m.Version, "name", m.Name)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	if len(applied) == 0 {
+		logger.Info("database schema is up to date")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+	"github.com/Jersonmade/pr-reviewer-service/internal/migrate"
+	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"github.com/Jersonmade/pr-reviewer-service/migrations"
+)
+
+// addPostgresChecks makes /readyz depend on the database answering and on
+// its schema having every migration this binary knows about.
+func addPostgresChecks(health *handlers.HealthHandler, store *storage.PostgresStorage, logger *slog.Logger) error {
+	migrator, err := migrate.New(store.DB(), migrations.Files, logger)
+
+	if err != nil {
+		return err
+	}
+
+	health.AddCheck("database", func(ctx context.Context) (interface{}, error) {
+		err := store.Ping(ctx)
+
+		return poolStats(store.PoolStats()), err
+	})
+
+	health.AddCheck("migrations", func(ctx context.Context) (interface{}, error) {
+		pending, err := migrator.Pending(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(pending) == 0 {
+			return nil, nil
+		}
+
+		names := make([]string, 0, len(pending))
+		for _, m := range pending {
+			names = append(names, fmt.Sprintf("%03d_%s", m.Version, m.Name))
+		}
+
+		return map[string]interface{}{"pending": names}, fmt.Errorf("%d migrations pending", len(pending))
+	})
+
+	return nil
+}
+
+func poolStats(stats sql.DBStats) map[string]interface{} {
+	return map[string]interface{}{
+		"max_open_connections": stats.MaxOpenConnections,
+		"open_connections":     stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration":        stats.WaitDuration.String(),
+		"max_idle_closed":      stats.MaxIdleClosed,
+		"max_idle_time_closed": stats.MaxIdleTimeClosed,
+		"max_lifetime_closed":  stats.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/config"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+)
+
+// runTokens implements the tokens subcommand, which is how the first admin
+// token gets issued. Like migrate it always works on PostgreSQL.
+func runTokens(cfg *config.Config, args []string, logger *slog.Logger) error {
+	if len(args) == 0 {
+		flag.Usage()
+		return fmt.Errorf("missing tokens command")
+	}
+
+	store, err := newPostgresStore(cfg.Database, logger)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.Error("failed to close store", "error", err)
+		}
+	}()
+
+	tokenService := services.NewTokenService(store, logger)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("tokens create", flag.ContinueOnError)
+		name := fs.String("name", "", "what the token is for")
+		role := fs.String("role", "", "admin, team_lead, member or bot")
+		teamName := fs.String("team", "", "team a team_lead token is scoped to")
+		userID := fs.String("user", "", "user a member token acts as")
+
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		token, err := tokenService.CreateToken(ctx, &models.APIToken{
+			Name:     *name,
+			Role:     *role,
+			TeamName: *teamName,
+			UserID:   *userID,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		// Only the token goes to stdout, so it can be captured by a script.
+		logger.Info("created token; it is not shown again", "token_id", token.TokenID, "role", token.Role)
+		fmt.Println(token.Token)
+
+		return nil
+	case "list":
+		tokens, err := tokenService.ListTokens(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tROLE\tSCOPE\tREVOKED AT")
+
+		for _, token := range tokens {
+			revokedAt := "-"
+			if token.RevokedAt != nil {
+				revokedAt = token.RevokedAt.Format("2006-01-02 15:04:05")
+			}
+
+			scope := token.TeamName + token.UserID
+			if scope == "" {
+				scope = "-"
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", token.TokenID, token.Name, token.Role, scope, revokedAt)
+		}
+
+		return w.Flush()
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: tokens revoke ID")
+		}
+
+		tokenID, err := strconv.ParseInt(args[1], 10, 64)
+
+		if err != nil {
+			return fmt.Errorf("invalid token id %q", args[1])
+		}
+
+		if _, err := tokenService.RevokeToken(ctx, tokenID); err != nil {
+			return err
+		}
+
+		logger.Info("revoked token", "token_id", tokenID)
+
+		return nil
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown tokens command %q", args[0])
+	}
+}
+
+// issueBootstrapToken gives the in-memory store, which starts empty and
+// cannot be reached by the tokens subcommand, an admin token for the life
+// of the process. Only the token goes to stdout, as with tokens create.
+func issueBootstrapToken(ctx context.Context, tokenService *services.TokenService, logger *slog.Logger) error {
+	token, err := tokenService.CreateToken(ctx, &models.APIToken{
+		Name: "bootstrap",
+		Role: auth.RoleAdmin,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("issued an admin token for the in-memory store; it is not shown again", "token_id", token.TokenID)
+	fmt.Println(token.Token)
+
+	return nil
+}
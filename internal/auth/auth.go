@@ -0,0 +1,101 @@
+// Package auth defines the API roles, what each of them is allowed to do,
+// and the principal an authenticated request acts as.
+package auth
+
+import "context"
+
+const (
+	RoleAdmin    = "admin"
+	RoleTeamLead = "team_lead"
+	RoleMember   = "member"
+	RoleBot      = "bot"
+)
+
+// Permission is what a route requires. Roles grant a fixed set of them;
+// services additionally check that team leads and members only act on their
+// own team or pull requests.
+type Permission string
+
+const (
+	PermRead       Permission = "read"
+	PermPRWrite    Permission = "pr:write"
+	PermPRMerge    Permission = "pr:merge"
+	PermTeamManage Permission = "team:manage"
+	PermAdmin      Permission = "admin"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleAdmin:    {PermRead, PermPRWrite, PermPRMerge, PermTeamManage, PermAdmin},
+	RoleTeamLead: {PermRead, PermPRWrite, PermPRMerge, PermTeamManage},
+	RoleMember:   {PermRead, PermPRWrite, PermPRMerge},
+	RoleBot:      {PermRead},
+}
+
+// IsValidRole reports whether role is one of the known roles.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+
+	return ok
+}
+
+// Principal is who a request acts as. TeamName is set for team leads and
+// UserID for members.
+type Principal struct {
+	TokenID  int64
+	Name     string
+	Role     string
+	TeamName string
+	UserID   string
+}
+
+// Can reports whether the principal's role grants perm.
+func (p *Principal) Can(perm Permission) bool {
+	for _, granted := range rolePermissions[p.Role] {
+		if granted == perm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Actor is how changes made with the principal's token are attributed:
+// the user for member tokens, the token's name otherwise.
+func (p *Principal) Actor() string {
+	if p.UserID != "" {
+		return p.UserID
+	}
+
+	return "token:" + p.Name
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of the request, or nil when the request
+// was not authenticated, which is the case when authentication is disabled.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+
+	return p
+}
+
+type actorKey struct{}
+
+// WithActor returns a context attributing the changes made under it to
+// actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns who the changes made under ctx are attributed to, or ""
+// when that is unknown.
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+
+	return actor
+}
@@ -0,0 +1,653 @@
+// Package config loads the server configuration. Every setting has a
+// built-in default and can be overridden, in increasing order of precedence,
+// by the YAML file, by an environment variable and by a command-line flag.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/limits"
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Storage     string            `yaml:"storage"`
+	HTTP        HTTPConfig        `yaml:"http"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Reviewers   ReviewerConfig    `yaml:"reviewers"`
+	Features    FeatureConfig     `yaml:"features"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Auth        AuthConfig        `yaml:"auth"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+}
+
+type HTTPConfig struct {
+	Port              int           `yaml:"port"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// DrainDelay is how long /readyz reports "draining" before the listener
+	// closes; ShutdownTimeout then bounds how long in-flight requests get.
+	DrainDelay       time.Duration `yaml:"drain_delay"`
+	ShutdownTimeout  time.Duration `yaml:"shutdown_timeout"`
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout"`
+	MaxBodyBytes     int           `yaml:"max_body_bytes"`
+}
+
+// DatabaseConfig describes the PostgreSQL connection. DSN, when set, is used
+// as is and the individual connection fields are ignored.
+type DatabaseConfig struct {
+	DSN             string        `yaml:"dsn"`
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"sslmode"`
+	SSLRootCert     string        `yaml:"sslrootcert"`
+	SSLCert         string        `yaml:"sslcert"`
+	SSLKey          string        `yaml:"sslkey"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	MigrateOnStart  bool          `yaml:"migrate_on_start"`
+}
+
+// ReviewerConfig holds the defaults for teams created without their own
+// reviewer settings.
+type ReviewerConfig struct {
+	Strategy     string `yaml:"strategy"`
+	MaxReviewers int    `yaml:"max_reviewers"`
+}
+
+type FeatureConfig struct {
+	ForceMerge  bool `yaml:"force_merge"`
+	BackupTeams bool `yaml:"backup_teams"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// TracingConfig selects where spans go: nowhere, stdout, or an OTLP/HTTP
+// collector. An empty endpoint leaves it to the OTEL_EXPORTER_OTLP_*
+// variables.
+type TracingConfig struct {
+	Exporter string `yaml:"exporter"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+// AuthConfig turns bearer-token authentication on or off. Tokens live in
+// the store and are issued with the tokens subcommand or /tokens/create;
+// the in-memory store issues an admin token at startup instead.
+// With authentication off, ActorHeader names the header a trusted gateway
+// sets to who is making the request.
+type AuthConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	ActorHeader string `yaml:"actor_header"`
+}
+
+// RateLimitConfig sets the token bucket every client gets on every route.
+// Routes overrides it per route as "/route=PER_MINUTE[:BURST],...". The
+// postgres backend shares the buckets between replicas.
+type RateLimitConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	Backend        string `yaml:"backend"`
+	PerMinute      int    `yaml:"per_minute"`
+	Burst          int    `yaml:"burst"`
+	Routes         string `yaml:"routes"`
+	ClientIPHeader string `yaml:"client_ip_header"`
+}
+
+// IdempotencyConfig controls how long responses to requests sent with an
+// Idempotency-Key are kept for replay.
+type IdempotencyConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"`
+}
+
+// Default returns the configuration used when nothing is overridden. It is
+// meant for production: PostgreSQL storage migrated on start, and
+// authentication, rate limiting and idempotency keys on, so a deployment
+// gets these protections unless it turns them off.
+func Default() *Config {
+	options := services.DefaultOptions()
+
+	return &Config{
+		Storage: "postgres",
+		HTTP: HTTPConfig{
+			Port:              8080,
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			DrainDelay:        3 * time.Second,
+			ShutdownTimeout:   15 * time.Second,
+			ReadinessTimeout:  2 * time.Second,
+			MaxBodyBytes:      1 << 20,
+		},
+		Database: DatabaseConfig{
+			Host:            "postgres_db",
+			Port:            5432,
+			User:            "postgres",
+			Password:        "postgres",
+			Name:            "pr_reviewer_service",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: 10 * time.Minute,
+			MigrateOnStart:  true,
+		},
+		Reviewers: ReviewerConfig{
+			Strategy:     options.ReviewerStrategy,
+			MaxReviewers: options.MaxReviewers,
+		},
+		Features: FeatureConfig{
+			ForceMerge:  options.AllowForceMerge,
+			BackupTeams: options.UseBackupTeams,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter: "off",
+		},
+		Auth: AuthConfig{
+			Enabled:     true,
+			ActorHeader: "X-Actor",
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   true,
+			Backend:   "memory",
+			PerMinute: 600,
+			Burst:     100,
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled: true,
+			TTL:     24 * time.Hour,
+		},
+	}
+}
+
+// setting binds one configuration value to its environment variable and
+// flag. The table below is the single list of what can be configured.
+type setting struct {
+	key    string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	field  func(c *Config) interface{}
+}
+
+var settings = []setting{
+	{"storage", "STORAGE", "storage", "storage backend: postgres, or memory for local demos (data is lost on exit)", false, func(c *Config) interface{} { return &c.Storage }},
+
+	{"http.port", "PORT", "port", "HTTP listen port", false, func(c *Config) interface{} { return &c.HTTP.Port }},
+	{"http.read_timeout", "HTTP_READ_TIMEOUT", "http-read-timeout", "maximum time to read a request", false, func(c *Config) interface{} { return &c.HTTP.ReadTimeout }},
+	{"http.read_header_timeout", "HTTP_READ_HEADER_TIMEOUT", "http-read-header-timeout", "maximum time to read request headers", false, func(c *Config) interface{} { return &c.HTTP.ReadHeaderTimeout }},
+	{"http.write_timeout", "HTTP_WRITE_TIMEOUT", "http-write-timeout", "maximum time to write a response", false, func(c *Config) interface{} { return &c.HTTP.WriteTimeout }},
+	{"http.idle_timeout", "HTTP_IDLE_TIMEOUT", "http-idle-timeout", "how long idle keep-alive connections are kept", false, func(c *Config) interface{} { return &c.HTTP.IdleTimeout }},
+	{"http.drain_delay", "HTTP_DRAIN_DELAY", "http-drain-delay", "how long /readyz reports draining before the listener closes on shutdown", false, func(c *Config) interface{} { return &c.HTTP.DrainDelay }},
+	{"http.readiness_timeout", "HTTP_READINESS_TIMEOUT", "http-readiness-timeout", "deadline for the /readyz dependency checks", false, func(c *Config) interface{} { return &c.HTTP.ReadinessTimeout }},
+	{"http.shutdown_timeout", "HTTP_SHUTDOWN_TIMEOUT", "http-shutdown-timeout", "how long in-flight requests may take to finish on shutdown", false, func(c *Config) interface{} { return &c.HTTP.ShutdownTimeout }},
+	{"http.max_body_bytes", "HTTP_MAX_BODY_BYTES", "http-max-body-bytes", "largest request body accepted, larger ones get 413", false, func(c *Config) interface{} { return &c.HTTP.MaxBodyBytes }},
+
+	{"database.dsn", "DB_DSN", "db-dsn", "PostgreSQL connection string, overrides the other connection settings", true, func(c *Config) interface{} { return &c.Database.DSN }},
+	{"database.host", "DB_HOST", "db-host", "PostgreSQL host", false, func(c *Config) interface{} { return &c.Database.Host }},
+	{"database.port", "DB_PORT", "db-port", "PostgreSQL port", false, func(c *Config) interface{} { return &c.Database.Port }},
+	{"database.user", "DB_USER", "db-user", "PostgreSQL user", false, func(c *Config) interface{} { return &c.Database.User }},
+	{"database.password", "DB_PASSWORD", "db-password", "PostgreSQL password", true, func(c *Config) interface{} { return &c.Database.Password }},
+	{"database.name", "DB_NAME", "db-name", "PostgreSQL database name", false, func(c *Config) interface{} { return &c.Database.Name }},
+	{"database.sslmode", "DB_SSLMODE", "db-sslmode", "TLS mode: disable, allow, prefer, require, verify-ca or verify-full", false, func(c *Config) interface{} { return &c.Database.SSLMode }},
+	{"database.sslrootcert", "DB_SSLROOTCERT", "db-sslrootcert", "CA certificate file used to verify the server", false, func(c *Config) interface{} { return &c.Database.SSLRootCert }},
+	{"database.sslcert", "DB_SSLCERT", "db-sslcert", "client certificate file", false, func(c *Config) interface{} { return &c.Database.SSLCert }},
+	{"database.sslkey", "DB_SSLKEY", "db-sslkey", "client private key file", false, func(c *Config) interface{} { return &c.Database.SSLKey }},
+	{"database.max_open_conns", "DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum open connections, 0 for unlimited", false, func(c *Config) interface{} { return &c.Database.MaxOpenConns }},
+	{"database.max_idle_conns", "DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum idle connections", false, func(c *Config) interface{} { return &c.Database.MaxIdleConns }},
+	{"database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "maximum connection age, 0 for no limit", false, func(c *Config) interface{} { return &c.Database.ConnMaxLifetime }},
+	{"database.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "maximum connection idle time, 0 for no limit", false, func(c *Config) interface{} { return &c.Database.ConnMaxIdleTime }},
+	{"database.migrate_on_start", "MIGRATE_ON_START", "migrate", "apply pending schema migrations at startup (postgres only)", false, func(c *Config) interface{} { return &c.Database.MigrateOnStart }},
+
+	{"reviewers.strategy", "REVIEWER_STRATEGY", "reviewer-strategy", "default reviewer_strategy for new teams", false, func(c *Config) interface{} { return &c.Reviewers.Strategy }},
+	{"reviewers.max_reviewers", "MAX_REVIEWERS", "max-reviewers", "default max_reviewers for new teams", false, func(c *Config) interface{} { return &c.Reviewers.MaxReviewers }},
+
+	{"features.force_merge", "FEATURE_FORCE_MERGE", "feature-force-merge", "allow merging PRs that do not satisfy the merge rules", false, func(c *Config) interface{} { return &c.Features.ForceMerge }},
+	{"features.backup_teams", "FEATURE_BACKUP_TEAMS", "feature-backup-teams", "borrow reviewers from backup teams", false, func(c *Config) interface{} { return &c.Features.BackupTeams }},
+
+	{"log.level", "LOG_LEVEL", "log-level", "minimum log level: debug, info, warn or error", false, func(c *Config) interface{} { return &c.Log.Level }},
+	{"log.format", "LOG_FORMAT", "log-format", "log format: json, or text for local development", false, func(c *Config) interface{} { return &c.Log.Format }},
+
+	{"tracing.exporter", "TRACING_EXPORTER", "tracing-exporter", "where to send trace spans: off, stdout or otlp", false, func(c *Config) interface{} { return &c.Tracing.Exporter }},
+	{"tracing.endpoint", "TRACING_ENDPOINT", "tracing-endpoint", "OTLP/HTTP collector URL, e.g. http://collector:4318 (default from OTEL_EXPORTER_OTLP_ENDPOINT)", false, func(c *Config) interface{} { return &c.Tracing.Endpoint }},
+
+	{"auth.enabled", "AUTH_ENABLED", "auth", "require a bearer token on API routes", false, func(c *Config) interface{} { return &c.Auth.Enabled }},
+	{"auth.actor_header", "AUTH_ACTOR_HEADER", "auth-actor-header", "trusted header naming who makes the request when auth is off, empty to ignore it", false, func(c *Config) interface{} { return &c.Auth.ActorHeader }},
+
+	{"rate_limit.enabled", "RATE_LIMIT_ENABLED", "rate-limit", "rate limit every client per route", false, func(c *Config) interface{} { return &c.RateLimit.Enabled }},
+	{"rate_limit.backend", "RATE_LIMIT_BACKEND", "rate-limit-backend", "where buckets live: memory (per replica) or postgres (shared)", false, func(c *Config) interface{} { return &c.RateLimit.Backend }},
+	{"rate_limit.per_minute", "RATE_LIMIT_PER_MINUTE", "rate-limit-per-minute", "requests a minute per client and route", false, func(c *Config) interface{} { return &c.RateLimit.PerMinute }},
+	{"rate_limit.burst", "RATE_LIMIT_BURST", "rate-limit-burst", "requests a client may make at once on a route", false, func(c *Config) interface{} { return &c.RateLimit.Burst }},
+	{"rate_limit.routes", "RATE_LIMIT_ROUTES", "rate-limit-routes", "per-route quotas, e.g. /pullRequest/create=60:10,/pullRequest/merge=120", false, func(c *Config) interface{} { return &c.RateLimit.Routes }},
+	{"rate_limit.client_ip_header", "RATE_LIMIT_CLIENT_IP_HEADER", "rate-limit-client-ip-header", "header a trusted proxy sets to the client address, e.g. X-Forwarded-For (default the connection address)", false, func(c *Config) interface{} { return &c.RateLimit.ClientIPHeader }},
+
+	{"idempotency.enabled", "IDEMPOTENCY_ENABLED", "idempotency", "replay responses to POST requests retried with the same Idempotency-Key", false, func(c *Config) interface{} { return &c.Idempotency.Enabled }},
+	{"idempotency.ttl", "IDEMPOTENCY_TTL", "idempotency-ttl", "how long responses are kept for replay", false, func(c *Config) interface{} { return &c.Idempotency.TTL }},
+}
+
+// Load registers the configuration flags on fs, parses args and builds the
+// effective configuration from the defaults, the YAML file named by -config
+// or CONFIG_FILE, the environment and the flags, in that order. The result
+// is validated. Arguments left after the flags are available from fs.Args.
+func Load(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	configFile := fs.String("config", "", "path to a YAML configuration file (also CONFIG_FILE)")
+
+	defaults := Default()
+	flagValues := make(map[string]string)
+
+	for _, s := range settings {
+		usage := fmt.Sprintf("%s (env %s, default %s)", s.usage, s.env, format(s.field(defaults), s.secret))
+
+		record := func(value string) error {
+			if err := set(s.field(Default()), value); err != nil {
+				return err
+			}
+
+			flagValues[s.flag] = value
+
+			return nil
+		}
+
+		if _, ok := s.field(defaults).(*bool); ok {
+			fs.BoolFunc(s.flag, usage, record)
+		} else {
+			fs.Func(s.flag, usage, record)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv("CONFIG_FILE")
+	}
+
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := lookupEnv(s.env); ok && value != "" {
+			if err := set(s.field(cfg), value); err != nil {
+				return nil, fmt.Errorf("%s (env %s): %w", s.key, s.env, err)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := flagValues[s.flag]; ok {
+			if err := set(s.field(cfg), value); err != nil {
+				return nil, fmt.Errorf("%s (flag -%s): %w", s.key, s.flag, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func set(field interface{}, value string) error {
+	switch p := field.(type) {
+	case *string:
+		*p = value
+	case *int:
+		n, err := strconv.Atoi(value)
+
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+
+		*p = n
+	case *bool:
+		b, err := strconv.ParseBool(value)
+
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+
+		*p = b
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+
+		*p = d
+	default:
+		return fmt.Errorf("unsupported setting type %T", field)
+	}
+
+	return nil
+}
+
+func format(field interface{}, secret bool) string {
+	var value string
+
+	switch p := field.(type) {
+	case *string:
+		value = *p
+	case *int:
+		value = strconv.Itoa(*p)
+	case *bool:
+		value = strconv.FormatBool(*p)
+	case *time.Duration:
+		value = p.String()
+	}
+
+	if secret && value != "" {
+		return redacted
+	}
+
+	if value == "" {
+		return `""`
+	}
+
+	return value
+}
+
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true,
+	"require": true, "verify-ca": true, "verify-full": true,
+}
+
+var logLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	fail := func(key, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Storage != "postgres" && c.Storage != "memory" {
+		fail("storage", "must be postgres or memory, got %q", c.Storage)
+	}
+
+	if c.HTTP.Port < 1 || c.HTTP.Port > 65535 {
+		fail("http.port", "must be between 1 and 65535")
+	}
+
+	for key, d := range map[string]time.Duration{
+		"http.read_timeout":           c.HTTP.ReadTimeout,
+		"http.read_header_timeout":    c.HTTP.ReadHeaderTimeout,
+		"http.write_timeout":          c.HTTP.WriteTimeout,
+		"http.idle_timeout":           c.HTTP.IdleTimeout,
+		"http.drain_delay":            c.HTTP.DrainDelay,
+		"database.conn_max_lifetime":  c.Database.ConnMaxLifetime,
+		"database.conn_max_idle_time": c.Database.ConnMaxIdleTime,
+	} {
+		if d < 0 {
+			fail(key, "cannot be negative")
+		}
+	}
+
+	if c.HTTP.ShutdownTimeout <= 0 {
+		fail("http.shutdown_timeout", "must be positive")
+	}
+
+	if c.HTTP.ReadinessTimeout <= 0 {
+		fail("http.readiness_timeout", "must be positive")
+	}
+
+	if c.HTTP.MaxBodyBytes < 1 {
+		fail("http.max_body_bytes", "must be positive")
+	}
+
+	db := c.Database
+
+	if db.DSN == "" {
+		if db.Host == "" {
+			fail("database.host", "cannot be empty")
+		}
+
+		if db.Port < 1 || db.Port > 65535 {
+			fail("database.port", "must be between 1 and 65535")
+		}
+
+		if db.User == "" {
+			fail("database.user", "cannot be empty")
+		}
+
+		if db.Name == "" {
+			fail("database.name", "cannot be empty")
+		}
+
+		if !sslModes[db.SSLMode] {
+			fail("database.sslmode", "unknown mode %q", db.SSLMode)
+		}
+
+		if (db.SSLCert == "") != (db.SSLKey == "") {
+			fail("database.sslcert", "sslcert and sslkey must be set together")
+		}
+
+		if db.SSLMode == "disable" && (db.SSLRootCert != "" || db.SSLCert != "") {
+			fail("database.sslmode", "certificates are set but TLS is disabled")
+		}
+	}
+
+	if db.MaxOpenConns < 0 {
+		fail("database.max_open_conns", "cannot be negative")
+	}
+
+	if db.MaxIdleConns < 0 {
+		fail("database.max_idle_conns", "cannot be negative")
+	}
+
+	if db.MaxOpenConns > 0 && db.MaxIdleConns > db.MaxOpenConns {
+		fail("database.max_idle_conns", "cannot exceed max_open_conns")
+	}
+
+	if !services.IsValidReviewerStrategy(c.Reviewers.Strategy) {
+		fail("reviewers.strategy", "unknown strategy %q", c.Reviewers.Strategy)
+	}
+
+	if c.Reviewers.MaxReviewers < 1 {
+		fail("reviewers.max_reviewers", "must be at least 1")
+	}
+
+	if !logLevels[strings.ToLower(c.Log.Level)] {
+		fail("log.level", "unknown level %q", c.Log.Level)
+	}
+
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		fail("log.format", "must be json or text, got %q", c.Log.Format)
+	}
+
+	switch c.Tracing.Exporter {
+	case "off", "stdout", "otlp":
+	default:
+		fail("tracing.exporter", "must be off, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+
+	if c.Tracing.Endpoint != "" {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			fail("tracing.endpoint", "must be a URL like http://collector:4318")
+		}
+	}
+
+	switch c.RateLimit.Backend {
+	case "memory":
+	case "postgres":
+		if c.Storage != "postgres" {
+			fail("rate_limit.backend", "postgres needs postgres storage")
+		}
+	default:
+		fail("rate_limit.backend", "must be memory or postgres, got %q", c.RateLimit.Backend)
+	}
+
+	if c.RateLimit.PerMinute < 1 {
+		fail("rate_limit.per_minute", "must be at least 1")
+	}
+
+	if c.RateLimit.Burst < 1 {
+		fail("rate_limit.burst", "must be at least 1")
+	}
+
+	if _, err := limits.ParseRouteQuotas(c.RateLimit.Routes); err != nil {
+		fail("rate_limit.routes", "%v", err)
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		fail("idempotency.ttl", "must be positive")
+	}
+
+	return errors.Join(errs...)
+}
+
+// ConnString returns the DSN to connect with.
+func (d DatabaseConfig) ConnString() string {
+	if d.DSN != "" {
+		return d.DSN
+	}
+
+	parts := []string{
+		"host=" + quote(d.Host),
+		"port=" + strconv.Itoa(d.Port),
+		"user=" + quote(d.User),
+		"password=" + quote(d.Password),
+		"dbname=" + quote(d.Name),
+		"sslmode=" + d.SSLMode,
+	}
+
+	optional := [][2]string{{"sslrootcert", d.SSLRootCert}, {"sslcert", d.SSLCert}, {"sslkey", d.SSLKey}}
+
+	for _, kv := range optional {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+"="+quote(kv[1]))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// quote escapes a value for a key=value connection string.
+func quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// ServiceOptions returns the settings the services honour.
+func (c *Config) ServiceOptions() services.Options {
+	return services.Options{
+		ReviewerStrategy: c.Reviewers.Strategy,
+		MaxReviewers:     c.Reviewers.MaxReviewers,
+		AllowForceMerge:  c.Features.ForceMerge,
+		UseBackupTeams:   c.Features.BackupTeams,
+	}
+}
+
+const redacted = "REDACTED"
+
+var dsnPassword = regexp.MustCompile(`(password=)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Redacted returns a copy of the configuration that is safe to print.
+func (c *Config) Redacted() *Config {
+	copied := *c
+
+	if copied.Database.Password != "" {
+		copied.Database.Password = redacted
+	}
+
+	copied.Database.DSN = redactDSN(copied.Database.DSN)
+
+	return &copied
+}
+
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+
+		q := u.Query()
+		if q.Has("password") {
+			q.Set("password", redacted)
+			u.RawQuery = q.Encode()
+		}
+
+		return u.String()
+	}
+
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
+
+// YAML renders the configuration in the format Load reads.
+func (c *Config) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(c); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/logging"
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+)
+
+// maxActorLength matches the width of the columns the actor is stored in.
+const maxActorLength = 255
+
+// Authenticator guards routes with bearer tokens and attributes the changes
+// a request makes to its actor. When disabled it lets every request through
+// unauthenticated, and the services then skip their team and pull request
+// checks too; the actor is then taken from actorHeader, which is trusted to
+// be set by a gateway in front of the service.
+type Authenticator struct {
+	responder
+	tokenService *services.TokenService
+	enabled      bool
+	actorHeader  string
+}
+
+func NewAuthenticator(tokenService *services.TokenService, enabled bool, actorHeader string, logger *slog.Logger) *Authenticator {
+	return &Authenticator{
+		responder:    responder{logger: logger},
+		tokenService: tokenService,
+		enabled:      enabled,
+		actorHeader:  actorHeader,
+	}
+}
+
+// authenticatedKey marks a request context whose caller was resolved
+// already, so that Middleware and Require do not look the token up twice.
+type authenticatedKey struct{}
+
+// Middleware resolves the caller of every request before the routes run, so
+// that the layers in between, such as rate limiting, know who is calling. A
+// missing or invalid token is only refused by Require, on the routes that
+// need one.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, ok := a.authenticate(w, r)
+
+		if !ok {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate puts the principal of a valid bearer token, or with auth
+// disabled the actor header, into the request context. It returns false if
+// it already responded.
+func (a *Authenticator) authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	ctx := context.WithValue(r.Context(), authenticatedKey{}, true)
+
+	if !a.enabled {
+		actor := ""
+		if a.actorHeader != "" {
+			actor = strings.TrimSpace(r.Header.Get(a.actorHeader))
+		}
+
+		if actor == "" {
+			return r.WithContext(ctx), true
+		}
+
+		if len(actor) > maxActorLength {
+			a.respondError(ctx, w, http.StatusBadRequest, "BAD_REQUEST", a.actorHeader+" header is too long")
+			return r, false
+		}
+
+		ctx = logging.With(ctx, "actor", actor)
+
+		return r.WithContext(auth.WithActor(ctx, actor)), true
+	}
+
+	scheme, secret, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(secret) == "" {
+		return r.WithContext(ctx), true
+	}
+
+	principal, err := a.tokenService.Authenticate(ctx, strings.TrimSpace(secret))
+
+	if err != nil {
+		if errors.Is(err, services.ErrUnauthorized) {
+			return r.WithContext(ctx), true
+		}
+
+		a.respondServiceError(ctx, w, err)
+		return r, false
+	}
+
+	ctx = logging.With(ctx, "token_id", principal.TokenID, "role", principal.Role, "actor", principal.Actor())
+	ctx = auth.WithPrincipal(ctx, principal)
+
+	return r.WithContext(auth.WithActor(ctx, principal.Actor())), true
+}
+
+// Require wraps next so that it only runs for callers whose role grants
+// perm. Requests without a valid token get 401, others 403.
+func (a *Authenticator) Require(perm auth.Permission, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(authenticatedKey{}) == nil {
+			var ok bool
+
+			if r, ok = a.authenticate(w, r); !ok {
+				return
+			}
+		}
+
+		if !a.enabled {
+			next(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		principal := auth.FromContext(ctx)
+
+		if principal == nil {
+			challenge := `Bearer realm="pr-reviewer-service"`
+			if r.Header.Get("Authorization") != "" {
+				challenge += `, error="invalid_token"`
+			}
+
+			w.Header().Set("WWW-Authenticate", challenge)
+			a.respondServiceError(ctx, w, services.ErrUnauthorized)
+			return
+		}
+
+		if !principal.Can(perm) {
+			a.respondServiceError(ctx, w, services.ErrForbidden.WithMessage("role %s does not grant %s", principal.Role, perm))
+			return
+		}
+
+		next(w, r)
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ReadinessCheck tells whether one dependency is usable. Details, which may
+// be returned even on failure, are included in the /readyz response.
+type ReadinessCheck func(ctx context.Context) (details interface{}, err error)
+
+type namedCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+type checkResult struct {
+	Status  string      `json:"status"`
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// HealthHandler serves the probes. /livez only says the process is up.
+// /readyz runs every registered check and answers 503 if one fails, or once
+// shutdown starts, so that traffic stops being routed here while in-flight
+// requests finish.
+type HealthHandler struct {
+	responder
+	timeout  time.Duration
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+// NewHealthHandler returns a handler whose readiness checks share a deadline
+// of timeout.
+func NewHealthHandler(timeout time.Duration, logger *slog.Logger) *HealthHandler {
+	return &HealthHandler{responder: responder{logger: logger}, timeout: timeout}
+}
+
+// AddCheck registers a readiness check. It must be called before serving.
+func (h *HealthHandler) AddCheck(name string, check ReadinessCheck) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// SetDraining marks the instance as shutting down.
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(r.Context(), w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		h.respondJSON(r.Context(), w, http.StatusServiceUnavailable, map[string]string{"status": "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	status := http.StatusOK
+	results := make(map[string]checkResult, len(h.checks))
+
+	for _, c := range h.checks {
+		details, err := c.check(ctx)
+		result := checkResult{Status: "ok", Details: details}
+
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			result.Status = "unavailable"
+			result.Error = err.Error()
+		}
+
+		results[c.name] = result
+	}
+
+	overall := "ok"
+	if status != http.StatusOK {
+		overall = "unavailable"
+	}
+
+	h.respondJSON(r.Context(), w, status, map[string]interface{}{
+		"status": overall,
+		"checks": results,
+	})
+}
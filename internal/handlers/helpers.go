@@ -1,24 +1,78 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
 )
 
-func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+// RespondError writes an error response for the middlewares outside this
+// package; logger reports a response that could not be written.
+func RespondError(ctx context.Context, w http.ResponseWriter, logger *slog.Logger, status int, code, message string) {
+	responder{logger: logger}.respondError(ctx, w, status, code, message)
+}
+
+// responder is embedded in the handlers and writes their responses.
+type responder struct {
+	logger *slog.Logger
+}
+
+func (rs responder) respondJSON(ctx context.Context, w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("failed to encode JSON response: %v", err)
+		rs.logger.WarnContext(ctx, "failed to encode JSON response", "error", err)
 	}
 }
 
-func RespondError(w http.ResponseWriter, status int, code, message string) {
-	respondJSON(w, status, models.ErrorResponse{
+func (rs responder) respondError(ctx context.Context, w http.ResponseWriter, status int, code, message string) {
+	rs.respondJSON(ctx, w, status, models.ErrorResponse{
 		Error: models.ErrorDetail{Code: code, Message: message},
 	})
 }
+
+// decodeJSON reads the request body into v. On failure it responds, with 413
+// if the body was over the size limit and 400 otherwise, and returns false.
+func (rs responder) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+
+	if errors.As(err, &tooLarge) {
+		rs.respondError(r.Context(), w, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+		return false
+	}
+
+	rs.respondError(r.Context(), w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+
+	return false
+}
+
+// respondServiceError is the single place where errors returned by the
+// services become HTTP responses. Domain errors carry their own status, code
+// and details; anything else is unexpected, so it is logged and reported as
+// INTERNAL_ERROR without leaking its message.
+func (rs responder) respondServiceError(ctx context.Context, w http.ResponseWriter, err error) {
+	var domainErr *services.DomainError
+
+	if errors.As(err, &domainErr) {
+		rs.respondJSON(ctx, w, domainErr.Status, models.ErrorResponse{
+			Error: models.ErrorDetail{Code: domainErr.Code, Message: domainErr.Message, Details: domainErr.Details},
+		})
+		return
+	}
+
+	rs.logger.ErrorContext(ctx, "internal error", "error", err)
+	rs.respondError(ctx, w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+}
@@ -1,23 +1,27 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
+	"log/slog"
 	"net/http"
 
+	"github.com/Jersonmade/pr-reviewer-service/internal/logging"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
 	"github.com/Jersonmade/pr-reviewer-service/internal/services"
 )
 
 type PRHandler struct {
+	responder
 	prService *services.PRService
 }
 
-func NewPRHandler(prService *services.PRService) *PRHandler {
-	return &PRHandler{prService: prService}
+func NewPRHandler(prService *services.PRService, logger *slog.Logger) *PRHandler {
+	return &PRHandler{responder: responder{logger: logger}, prService: prService}
 }
 
 func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		RespondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
 		return
 	}
 
@@ -27,35 +31,29 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 		PullRequestID   string `json:"pull_request_id"`
 		PullRequestName string `json:"pull_request_name"`
 		AuthorID        string `json:"author_id"`
+		ReviewersCount  int    `json:"reviewers_count"`
+		Draft           bool   `json:"draft"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		RespondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
 
-	pr, err := h.prService.CreatePR(ctx, req.PullRequestID, req.PullRequestName, req.AuthorID)
+	ctx = logging.With(ctx, "pr_id", req.PullRequestID, "user_id", req.AuthorID)
+
+	pr, err := h.prService.CreatePR(ctx, req.PullRequestID, req.PullRequestName, req.AuthorID, req.ReviewersCount, req.Draft)
 
 	if err != nil {
-		switch err.Error() {
-		case "AUTHOR_NOT_FOUND", "USER_NOT_FOUND":
-			RespondError(w, http.StatusNotFound, "NOT_FOUND", "author not found")
-		case "PR_EXISTS":
-			RespondError(w, http.StatusConflict, "PR_EXISTS", "PR id already exists")
-		case "pull_request_id cannot be empty", "pull_request_name cannot be empty", "author_id cannot be empty":
-			RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
-		default:
-			RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
-		}
+		h.respondServiceError(ctx, w, err)
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, map[string]interface{}{"pr": pr})
+	h.respondJSON(ctx, w, http.StatusCreated, map[string]interface{}{"pr": pr})
 }
 
 func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		RespondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
 		return
 	}
 
@@ -63,31 +61,132 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
+		Force         bool   `json:"force"`
+		Reason        string `json:"reason"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		RespondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
 
-	pr, err := h.prService.MergePR(ctx, req.PullRequestID)
+	ctx = logging.With(ctx, "pr_id", req.PullRequestID)
+
+	pr, err := h.prService.MergePR(ctx, req.PullRequestID, req.Force, req.Reason)
 
 	if err != nil {
-		if err.Error() == "PR_NOT_FOUND" {
-			RespondError(w, http.StatusNotFound, "NOT_FOUND", "PR not found")
-		} else {
-			RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
-		}
+		h.respondServiceError(ctx, w, err)
+		return
+	}
 
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{"pr": pr})
+}
+
+func (h *PRHandler) SubmitReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{"pr": pr})
+	ctx := r.Context()
+
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+		ReviewerID    string `json:"reviewer_id"`
+		Decision      string `json:"decision"`
+		Body          string `json:"body"`
+	}
+
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx = logging.With(ctx, "pr_id", req.PullRequestID, "user_id", req.ReviewerID)
+
+	pr, err := h.prService.SubmitReview(ctx, req.PullRequestID, &models.Review{
+		ReviewerID: req.ReviewerID,
+		Decision:   req.Decision,
+		Body:       req.Body,
+	})
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{"pr": pr})
+}
+
+func (h *PRHandler) GetPRHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET allowed")
+		return
+	}
+
+	prID := r.URL.Query().Get("pull_request_id")
+
+	if prID == "" {
+		h.respondError(r.Context(), w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id query parameter required")
+		return
+	}
+
+	ctx := logging.With(r.Context(), "pr_id", prID)
+
+	events, err := h.prService.GetPRHistory(ctx, prID)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{
+		"pull_request_id": prID,
+		"events":          events,
+	})
+}
+
+func (h *PRHandler) ClosePR(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.prService.ClosePR)
+}
+
+func (h *PRHandler) ReopenPR(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.prService.ReopenPR)
+}
+
+func (h *PRHandler) MarkReady(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.prService.MarkReady)
+}
+
+// transition handles the lifecycle endpoints that take only a PR id and
+// respond with the updated PR.
+func (h *PRHandler) transition(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, prID string) (*models.PullRequest, error)) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		return
+	}
+
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+	}
+
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx := logging.With(r.Context(), "pr_id", req.PullRequestID)
+
+	pr, err := apply(ctx, req.PullRequestID)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{"pr": pr})
 }
 
 func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		RespondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
 		return
 	}
 
@@ -96,34 +195,25 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
 		OldUserID     string `json:"old_user_id"`
+		Reason        string `json:"reason"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		RespondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
 
-	newReviewerID, err := h.prService.ReassignReviewer(ctx, req.PullRequestID, req.OldUserID)
+	ctx = logging.With(ctx, "pr_id", req.PullRequestID, "user_id", req.OldUserID)
+
+	newReviewerID, err := h.prService.ReassignReviewer(ctx, req.PullRequestID, req.OldUserID, req.Reason)
 
 	if err != nil {
-		switch err.Error() {
-		case "PR_NOT_FOUND", "USER_NOT_FOUND":
-			RespondError(w, http.StatusNotFound, "NOT_FOUND", "PR or user not found")
-		case "PR_MERGED":
-			RespondError(w, http.StatusConflict, "PR_MERGED", "cannot reassign on merged PR")
-		case "NOT_ASSIGNED":
-			RespondError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
-		case "NO_CANDIDATE":
-			RespondError(w, http.StatusConflict, "NO_CANDIDATE", "no active replacement candidate in team")
-		default:
-			RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
-		}
+		h.respondServiceError(ctx, w, err)
 		return
 	}
 
 	pr, _ := h.prService.GetPR(ctx, req.PullRequestID)
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{
 		"pr":          pr,
 		"replaced_by": newReviewerID,
 	})
@@ -1,22 +1,24 @@
 package handlers
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/Jersonmade/pr-reviewer-service/internal/services"
 )
 
 type AnalyticsHandler struct {
+	responder
 	analyticsService *services.StatsService
 }
 
-func NewAnalyticsHandler(analyticsService *services.StatsService) *AnalyticsHandler {
-	return &AnalyticsHandler{analyticsService: analyticsService}
+func NewAnalyticsHandler(analyticsService *services.StatsService, logger *slog.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{responder: responder{logger: logger}, analyticsService: analyticsService}
 }
 
 func (h *AnalyticsHandler) GetReviewAssignmentsStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		RespondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET allowed")
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET allowed")
 		return
 	}
 
@@ -24,11 +26,19 @@ func (h *AnalyticsHandler) GetReviewAssignmentsStats(w http.ResponseWriter, r *h
 	counts, err := h.analyticsService.GetReviewAssignmentsCount(ctx)
 
 	if err != nil {
-		RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		h.respondServiceError(ctx, w, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"review_assignments": counts,
+	byStatus, err := h.analyticsService.GetPRCountByStatus(ctx)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{
+		"review_assignments":      counts,
+		"pull_requests_by_status": byStatus,
 	})
 }
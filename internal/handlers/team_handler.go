@@ -1,87 +1,275 @@
 package handlers
 
 import (
-	"encoding/json"
+	"log/slog"
 	"net/http"
-	"strings"
 
+	"github.com/Jersonmade/pr-reviewer-service/internal/logging"
 	"github.com/Jersonmade/pr-reviewer-service/internal/models"
 	"github.com/Jersonmade/pr-reviewer-service/internal/services"
 )
 
 type TeamHandler struct {
+	responder
 	teamService *services.TeamService
+	prService   *services.PRService
 }
 
-func NewTeamHandler(teamService *services.TeamService) *TeamHandler {
-	return &TeamHandler{teamService: teamService}
+func NewTeamHandler(teamService *services.TeamService, prService *services.PRService, logger *slog.Logger) *TeamHandler {
+	return &TeamHandler{
+		responder:   responder{logger: logger},
+		teamService: teamService,
+		prService:   prService,
+	}
 }
 
 func (h *TeamHandler) AddTeam(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		RespondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
 		return
 	}
 
 	ctx := r.Context()
 	var team models.Team
 
-	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
-		RespondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+	if !h.decodeJSON(w, r, &team) {
 		return
 	}
 
+	ctx = logging.With(ctx, "team_name", team.TeamName)
+
 	createdTeam, err := h.teamService.CreateTeam(ctx, &team)
 
 	if err != nil {
-		switch err.Error() {
-		case "TEAM_EXISTS":
-			RespondError(w, http.StatusBadRequest, "TEAM_EXISTS", "team_name already exists")
-			return
-		case "team_name cannot be empty", "team must have at least one member":
-			RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
-			return
-		case "NOT_FOUND":
-			RespondError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
-			return
-		default:
-			if strings.HasPrefix(err.Error(), "duplicate user_id") ||
-				strings.HasPrefix(err.Error(), "member at index") {
-				RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
-				return
-			}
-			RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
-			return
-		}
-	}
-
-	respondJSON(w, http.StatusCreated, map[string]interface{}{"team": createdTeam})
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusCreated, map[string]interface{}{"team": createdTeam})
 }
 
 func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		RespondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET allowed")
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET allowed")
 		return
 	}
 
-	ctx := r.Context()
 	teamName := r.URL.Query().Get("team_name")
 
 	if teamName == "" {
-		RespondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name query parameter required")
+		h.respondError(r.Context(), w, http.StatusBadRequest, "BAD_REQUEST", "team_name query parameter required")
 		return
 	}
 
+	ctx := logging.With(r.Context(), "team_name", teamName)
+
 	team, err := h.teamService.GetTeam(ctx, teamName)
 
 	if err != nil {
-		if err.Error() == "NOT_FOUND" {
-			RespondError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
-		} else {
-			RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
-		}
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, team)
+}
+
+func (h *TeamHandler) UpdateTeamSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	var update models.TeamSettingsUpdate
+
+	if !h.decodeJSON(w, r, &update) {
+		return
+	}
+
+	ctx = logging.With(ctx, "team_name", update.TeamName)
+
+	settings, err := h.teamService.UpdateTeamSettings(ctx, &update)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{"settings": settings})
+}
+
+func (h *TeamHandler) DeactivateUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	var req struct {
+		TeamName string   `json:"team_name"`
+		UserIDs  []string `json:"user_ids"`
+	}
+
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx = logging.With(ctx, "team_name", req.TeamName)
+
+	result, err := h.prService.DeactivateTeamMembers(ctx, req.TeamName, req.UserIDs)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, result)
+}
+
+func (h *TeamHandler) AddMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	var req struct {
+		TeamName string              `json:"team_name"`
+		Members  []models.TeamMember `json:"members"`
+	}
+
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx = logging.With(ctx, "team_name", req.TeamName)
+
+	team, err := h.teamService.AddTeamMembers(ctx, req.TeamName, req.Members)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{"team": team})
+}
+
+func (h *TeamHandler) RemoveMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	var req struct {
+		TeamName string   `json:"team_name"`
+		UserIDs  []string `json:"user_ids"`
+	}
+
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx = logging.With(ctx, "team_name", req.TeamName)
+
+	result, err := h.prService.RemoveTeamMembers(ctx, req.TeamName, req.UserIDs)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, result)
+}
+
+func (h *TeamHandler) TransferUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	var req struct {
+		UserID      string `json:"user_id"`
+		ToTeam      string `json:"to_team"`
+		KeepReviews bool   `json:"keep_reviews"`
+	}
+
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx = logging.With(ctx, "user_id", req.UserID, "team_name", req.ToTeam)
+
+	result, err := h.prService.TransferUser(ctx, req.UserID, req.ToTeam, req.KeepReviews)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, result)
+}
+
+func (h *TeamHandler) RenameTeam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	var req struct {
+		TeamName    string `json:"team_name"`
+		NewTeamName string `json:"new_team_name"`
+	}
+
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx = logging.With(ctx, "team_name", req.TeamName)
+
+	team, err := h.teamService.RenameTeam(ctx, req.TeamName, req.NewTeamName)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{"team": team})
+}
+
+func (h *TeamHandler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	var req struct {
+		TeamName   string `json:"team_name"`
+		TargetTeam string `json:"target_team"`
+	}
+
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx = logging.With(ctx, "team_name", req.TeamName)
+
+	result, err := h.teamService.DeleteTeam(ctx, req.TeamName, req.TargetTeam)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, team)
+	h.respondJSON(ctx, w, http.StatusOK, result)
 }
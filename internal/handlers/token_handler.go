@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/logging"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+)
+
+type TokenHandler struct {
+	responder
+	tokenService *services.TokenService
+}
+
+func NewTokenHandler(tokenService *services.TokenService, logger *slog.Logger) *TokenHandler {
+	return &TokenHandler{responder: responder{logger: logger}, tokenService: tokenService}
+}
+
+func (h *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	var req struct {
+		Name     string `json:"name"`
+		Role     string `json:"role"`
+		TeamName string `json:"team_name"`
+		UserID   string `json:"user_id"`
+	}
+
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	token, err := h.tokenService.CreateToken(ctx, &models.APIToken{
+		Name:     req.Name,
+		Role:     req.Role,
+		TeamName: req.TeamName,
+		UserID:   req.UserID,
+	})
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusCreated, map[string]interface{}{"token": token})
+}
+
+func (h *TokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET allowed")
+		return
+	}
+
+	ctx := r.Context()
+	tokens, err := h.tokenService.ListTokens(ctx)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{"tokens": tokens})
+}
+
+func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		return
+	}
+
+	ctx := r.Context()
+
+	var req struct {
+		TokenID int64 `json:"token_id"`
+	}
+
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx = logging.With(ctx, "token_id", req.TokenID)
+
+	token, err := h.tokenService.RevokeToken(ctx, req.TokenID)
+
+	if err != nil {
+		h.respondServiceError(ctx, w, err)
+		return
+	}
+
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{"token": token})
+}
@@ -1,19 +1,22 @@
 package handlers
 
 import (
-	"encoding/json"
+	"log/slog"
 	"net/http"
 
+	"github.com/Jersonmade/pr-reviewer-service/internal/logging"
 	"github.com/Jersonmade/pr-reviewer-service/internal/services"
 )
 
 type UserHandler struct {
+	responder
 	userService *services.UserService
 	prService   *services.PRService
 }
 
-func NewUserHandler(userService *services.UserService, prService *services.PRService) *UserHandler {
+func NewUserHandler(userService *services.UserService, prService *services.PRService, logger *slog.Logger) *UserHandler {
 	return &UserHandler{
+		responder:   responder{logger: logger},
 		userService: userService,
 		prService:   prService,
 	}
@@ -21,7 +24,7 @@ func NewUserHandler(userService *services.UserService, prService *services.PRSer
 
 func (h *UserHandler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		RespondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only POST allowed")
 		return
 	}
 
@@ -32,58 +35,60 @@ func (h *UserHandler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 		IsActive bool   `json:"is_active"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		RespondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx = logging.With(ctx, "user_id", req.UserID)
+
+	if !req.IsActive {
+		result, err := h.prService.DeactivateUser(ctx, req.UserID)
+		if err != nil {
+			h.respondServiceError(ctx, w, err)
+			return
+		}
+
+		h.respondJSON(ctx, w, http.StatusOK, result)
 		return
 	}
 
 	user, err := h.userService.SetUserActive(ctx, req.UserID, req.IsActive)
 	if err != nil {
-		switch err.Error() {
-		case "USER_NOT_FOUND":
-			RespondError(w, http.StatusNotFound, "USER_NOT_FOUND", "user not found")
-		case "User ID cannot be empty":
-			RespondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
-		default:
-			RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
-		}
+		h.respondServiceError(ctx, w, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{"user": user})
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{"user": user})
 }
 
 func (h *UserHandler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		RespondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET allowed")
+		h.respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Only GET allowed")
 		return
 	}
 
-	ctx := r.Context()
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
-		RespondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id query parameter required")
+		h.respondError(r.Context(), w, http.StatusBadRequest, "BAD_REQUEST", "user_id query parameter required")
 		return
 	}
 
+	ctx := logging.With(r.Context(), "user_id", userID)
+
 	_, err := h.userService.GetUser(ctx, userID)
 
 	if err != nil {
-		if err.Error() == "USER_NOT_FOUND" {
-			RespondError(w, http.StatusNotFound, "USER_NOT_FOUND", "user not found")
-		} else {
-			RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
-		}
+		h.respondServiceError(ctx, w, err)
 		return
 	}
 
 	prs, err := h.prService.GetPRsByReviewer(ctx, userID)
 	if err != nil {
-		RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		h.respondServiceError(ctx, w, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
+	h.respondJSON(ctx, w, http.StatusOK, map[string]interface{}{
 		"user_id":       userID,
 		"pull_requests": prs,
 	})
@@ -0,0 +1,40 @@
+// Package idempotency makes POST requests safe to retry: the first response
+// to a request sent with an Idempotency-Key header is stored and replayed to
+// retries carrying the same key, instead of running the request again.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrKeyReused means the key was first used for a different request.
+	ErrKeyReused = errors.New("IDEMPOTENCY_KEY_REUSED")
+	// ErrInProgress means the first request with the key is still running.
+	ErrInProgress = errors.New("IDEMPOTENCY_KEY_IN_PROGRESS")
+)
+
+// lockTimeout is how long a key stays reserved for a request that never
+// completed, e.g. because the replica crashed, before it can be retried.
+const lockTimeout = time.Minute
+
+// Response is a stored response, replayed as is.
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// Store keeps the responses until their TTL runs out.
+//
+// Begin reserves key for a request identified by fingerprint. It returns nil
+// once reserved, the stored response if the same request already completed,
+// or ErrKeyReused or ErrInProgress. A reserved key is then either completed
+// with the response, or released so that the request can be retried.
+type Store interface {
+	Begin(ctx context.Context, key, fingerprint string) (*Response, error)
+	Complete(ctx context.Context, key string, resp Response) error
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,79 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	fingerprint string
+	resp        *Response
+	expires     time.Time
+}
+
+// MemoryStore keeps the responses in process, for the in-memory storage.
+type MemoryStore struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{ttl: ttl, entries: make(map[string]*entry), lastSweep: time.Now()}
+}
+
+func (m *MemoryStore) Begin(_ context.Context, key, fingerprint string) (*Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(m.lastSweep) > time.Hour {
+		for k, e := range m.entries {
+			if now.After(e.expires) {
+				delete(m.entries, k)
+			}
+		}
+
+		m.lastSweep = now
+	}
+
+	if e, ok := m.entries[key]; ok && now.Before(e.expires) {
+		switch {
+		case e.fingerprint != fingerprint:
+			return nil, ErrKeyReused
+		case e.resp == nil:
+			return nil, ErrInProgress
+		default:
+			return e.resp, nil
+		}
+	}
+
+	m.entries[key] = &entry{fingerprint: fingerprint, expires: now.Add(lockTimeout)}
+
+	return nil, nil
+}
+
+func (m *MemoryStore) Complete(_ context.Context, key string, resp Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok {
+		e.resp = &resp
+		e.expires = time.Now().Add(m.ttl)
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+
+	return nil
+}
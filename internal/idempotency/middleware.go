@@ -0,0 +1,183 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+)
+
+// Header is the request header carrying the key.
+const Header = "Idempotency-Key"
+
+const maxKeyLength = 255
+
+// Middleware replays stored responses to POST requests retried with the same
+// Idempotency-Key. Keys are scoped to the caller, so that clients cannot
+// replay each other's responses, and a key may only be reused for the same
+// route and body. Server errors are not stored, so a request that failed that
+// way runs again when retried.
+//
+// It must run after the Authenticator's middleware. Requests without a caller,
+// such as those with a revoked or invalid token, skip the stored responses
+// and go on to be refused by the route.
+func Middleware(store Store, mux *http.ServeMux, next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		caller := scope(r.Context())
+
+		if caller == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		_, route := mux.Handler(r)
+
+		if route == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			handlers.RespondError(r.Context(), w, logger, http.StatusBadRequest, "INVALID_IDEMPOTENCY_KEY",
+				fmt.Sprintf("%s must be at most %d characters", Header, maxKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+
+			if errors.As(err, &tooLarge) {
+				handlers.RespondError(r.Context(), w, logger, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE",
+					fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+				return
+			}
+
+			handlers.RespondError(r.Context(), w, logger, http.StatusBadRequest, "INVALID_BODY", "failed to read request body")
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := r.Context()
+		key = caller + " " + key
+		stored, err := store.Begin(ctx, key, fingerprint(route, body))
+
+		switch {
+		case errors.Is(err, ErrKeyReused):
+			handlers.RespondError(ctx, w, logger, http.StatusConflict, ErrKeyReused.Error(),
+				"idempotency key was already used for a different request")
+			return
+		case errors.Is(err, ErrInProgress):
+			w.Header().Set("Retry-After", "1")
+			handlers.RespondError(ctx, w, logger, http.StatusConflict, ErrInProgress.Error(),
+				"a request with this idempotency key is still in progress")
+			return
+		case err != nil:
+			logger.ErrorContext(ctx, "failed to look up idempotency key", "error", err)
+			handlers.RespondError(ctx, w, logger, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+			return
+		}
+
+		if stored != nil {
+			logger.InfoContext(ctx, "replaying idempotent response", "status", stored.StatusCode)
+
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			_, _ = w.Write(stored.Body)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// The response is stored even if the client went away meanwhile:
+		// that is exactly when it is going to retry.
+		ctx = context.WithoutCancel(ctx)
+
+		if rec.status >= http.StatusInternalServerError {
+			err = store.Release(ctx, key)
+		} else {
+			err = store.Complete(ctx, key, Response{
+				StatusCode:  rec.status,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+			})
+		}
+
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to store idempotent response", "error", err)
+		}
+	})
+}
+
+// scope identifies whose key it is: the authenticated token, or with auth
+// disabled the actor. It returns "" when the caller is unknown.
+func scope(ctx context.Context) string {
+	if p := auth.FromContext(ctx); p != nil {
+		return "token:" + strconv.FormatInt(p.TokenID, 10)
+	}
+
+	if actor := auth.Actor(ctx); actor != "" {
+		return "actor:" + actor
+	}
+
+	return ""
+}
+
+func fingerprint(route string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(route + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder passes the response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
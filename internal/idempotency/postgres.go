@@ -0,0 +1,125 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// PostgresStore keeps the responses in the idempotency_keys table, so that a
+// retry landing on another replica is replayed too.
+type PostgresStore struct {
+	db     *sql.DB
+	ttl    time.Duration
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresStore(db *sql.DB, ttl time.Duration, logger *slog.Logger) *PostgresStore {
+	return &PostgresStore{db: db, ttl: ttl, logger: logger, lastSweep: time.Now()}
+}
+
+func (s *PostgresStore) Begin(ctx context.Context, key, fingerprint string) (*Response, error) {
+	s.sweep(ctx)
+
+	// An expired key is taken over as if it had never been used.
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (idempotency_key, fingerprint, expires_at)
+		VALUES ($1, $2, now() + make_interval(secs => $3))
+		ON CONFLICT (idempotency_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = CURRENT_TIMESTAMP,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < now()
+	`, key, fingerprint, lockTimeout.Seconds())
+
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := res.RowsAffected()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if reserved == 1 {
+		return nil, nil
+	}
+
+	var (
+		stored      string
+		statusCode  sql.NullInt64
+		contentType sql.NullString
+		body        []byte
+	)
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT fingerprint, status_code, content_type, response_body
+		FROM idempotency_keys
+		WHERE idempotency_key = $1
+	`, key).Scan(&stored, &statusCode, &contentType, &body)
+
+	if err == sql.ErrNoRows {
+		// Swept between the two statements; the retry may reserve it.
+		return nil, ErrInProgress
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case stored != fingerprint:
+		return nil, ErrKeyReused
+	case !statusCode.Valid:
+		return nil, ErrInProgress
+	}
+
+	return &Response{StatusCode: int(statusCode.Int64), ContentType: contentType.String, Body: body}, nil
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, key string, resp Response) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $2, content_type = $3, response_body = $4,
+			expires_at = now() + make_interval(secs => $5)
+		WHERE idempotency_key = $1
+	`, key, resp.StatusCode, resp.ContentType, resp.Body, s.ttl.Seconds())
+
+	return err
+}
+
+func (s *PostgresStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE idempotency_key = $1 AND status_code IS NULL",
+		key,
+	)
+
+	return err
+}
+
+// sweep drops expired keys, at most once an hour per replica.
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	due := time.Since(s.lastSweep) > time.Hour
+	if due {
+		s.lastSweep = time.Now()
+	}
+	s.mu.Unlock()
+
+	if !due {
+		return
+	}
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < now()"); err != nil {
+		s.logger.WarnContext(ctx, "failed to drop expired idempotency keys", "error", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Package limits protects the API from misbehaving clients: it caps request
+// body sizes and rate limits every client per route with token buckets.
+package limits
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quota is a token bucket: it holds up to Burst requests and refills at
+// PerMinute requests a minute.
+type Quota struct {
+	PerMinute int
+	Burst     int
+}
+
+func (q Quota) rate() float64 {
+	return float64(q.PerMinute) / 60
+}
+
+// Store keeps the buckets. Take takes one request from the bucket under key,
+// refilled according to quota, and returns zero, or how long until a
+// request is available if the bucket is empty.
+type Store interface {
+	Take(ctx context.Context, key string, quota Quota) (time.Duration, error)
+}
+
+// take refills a bucket that held tokens elapsed ago and takes one request
+// from it. It returns the new level and how long to wait if nothing could be
+// taken.
+func take(tokens float64, elapsed time.Duration, quota Quota) (float64, time.Duration) {
+	tokens = math.Min(float64(quota.Burst), tokens+elapsed.Seconds()*quota.rate())
+
+	if tokens >= 1 {
+		return tokens - 1, 0
+	}
+
+	return tokens, time.Duration((1 - tokens) / quota.rate() * float64(time.Second))
+}
+
+// ParseRouteQuotas parses per-route quotas written as
+// "/route=PER_MINUTE[:BURST],...". Without a burst, a route can use up its
+// quota for one minute at once.
+func ParseRouteQuotas(s string) (map[string]Quota, error) {
+	quotas := make(map[string]Quota)
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		route, spec, ok := strings.Cut(entry, "=")
+
+		if !ok || !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("%q: must look like /route=PER_MINUTE[:BURST]", entry)
+		}
+
+		perMinute, burst, hasBurst := strings.Cut(spec, ":")
+
+		quota := Quota{}
+		var err error
+
+		if quota.PerMinute, err = strconv.Atoi(perMinute); err != nil || quota.PerMinute < 1 {
+			return nil, fmt.Errorf("%q: requests per minute must be a positive integer", entry)
+		}
+
+		quota.Burst = quota.PerMinute
+		if hasBurst {
+			if quota.Burst, err = strconv.Atoi(burst); err != nil || quota.Burst < 1 {
+				return nil, fmt.Errorf("%q: burst must be a positive integer", entry)
+			}
+		}
+
+		quotas[route] = quota
+	}
+
+	return quotas, nil
+}
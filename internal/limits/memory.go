@@ -0,0 +1,58 @@
+package limits
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// idleBucketTTL is how long a bucket that was not used is kept. Dropping it
+// only lets its client start again from a full bucket.
+const idleBucketTTL = time.Hour
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryStore keeps the buckets in process. Each replica then enforces the
+// quotas on its own; use PostgresStore to share them.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+func (m *MemoryStore) Take(_ context.Context, key string, quota Quota) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(m.lastSweep) > idleBucketTTL {
+		for k, b := range m.buckets {
+			if now.Sub(b.updated) > idleBucketTTL {
+				delete(m.buckets, k)
+			}
+		}
+
+		m.lastSweep = now
+	}
+
+	b, ok := m.buckets[key]
+
+	if !ok {
+		b = &bucket{tokens: float64(quota.Burst), updated: now}
+		m.buckets[key] = b
+	}
+
+	var wait time.Duration
+	b.tokens, wait = take(b.tokens, now.Sub(b.updated), quota)
+	b.updated = now
+
+	return wait, nil
+}
@@ -0,0 +1,127 @@
+package limits
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+	"github.com/Jersonmade/pr-reviewer-service/internal/metrics"
+)
+
+// MaxBody caps request bodies at maxBytes. Requests that announce a larger
+// body are refused with 413 right away; for the others the handlers report
+// 413 once reading goes past the limit.
+func MaxBody(maxBytes int64, next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			handlers.RespondError(r.Context(), w, logger, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE",
+				fmt.Sprintf("request body exceeds %d bytes", maxBytes))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Limiter rate limits each client separately on every route: a client is
+// the API token it was authenticated with, or its address for requests
+// without a valid one. Routes without their own quota use the default one.
+type Limiter struct {
+	store          Store
+	defaultQuota   Quota
+	routes         map[string]Quota
+	clientIPHeader string
+	logger         *slog.Logger
+}
+
+// NewLimiter returns a limiter keeping its buckets in store. When
+// clientIPHeader is set, the client address is taken from that header, which
+// a trusted proxy in front of the service must set, instead of from the
+// connection.
+func NewLimiter(store Store, defaultQuota Quota, routes map[string]Quota, clientIPHeader string, logger *slog.Logger) *Limiter {
+	return &Limiter{
+		store:          store,
+		defaultQuota:   defaultQuota,
+		routes:         routes,
+		clientIPHeader: clientIPHeader,
+		logger:         logger,
+	}
+}
+
+// Middleware answers 429 with Retry-After to clients over their quota on the
+// mux route they call. It must run after the Authenticator's middleware.
+// Routes in exempt, such as the probes, are not limited. If the store fails,
+// requests are let through rather than failing the API.
+func (l *Limiter) Middleware(mux *http.ServeMux, next http.Handler, exempt ...string) http.Handler {
+	skip := make(map[string]bool, len(exempt))
+	for _, route := range exempt {
+		skip[route] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+
+		if route == "" || skip[route] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		quota, ok := l.routes[route]
+		if !ok {
+			quota = l.defaultQuota
+		}
+
+		ctx := r.Context()
+		wait, err := l.store.Take(ctx, route+" "+l.client(r), quota)
+
+		if err != nil {
+			l.logger.WarnContext(ctx, "rate limiter unavailable, request let through", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if wait > 0 {
+			seconds := int(math.Ceil(wait.Seconds()))
+
+			metrics.RateLimited(route)
+			l.logger.InfoContext(ctx, "request rate limited", "retry_after_s", seconds)
+
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			handlers.RespondError(ctx, w, l.logger, http.StatusTooManyRequests, "RATE_LIMITED",
+				fmt.Sprintf("rate limit exceeded, retry in %d seconds", seconds))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// client identifies who is calling. The principal is only known once the
+// request went through authentication; a made-up token must not get its own
+// bucket, so anything else is keyed on the address.
+func (l *Limiter) client(r *http.Request) string {
+	if p := auth.FromContext(r.Context()); p != nil {
+		return "token:" + strconv.FormatInt(p.TokenID, 10)
+	}
+
+	if l.clientIPHeader != "" {
+		// X-Forwarded-For lists the original client first.
+		if ip, _, _ := strings.Cut(r.Header.Get(l.clientIPHeader), ","); strings.TrimSpace(ip) != "" {
+			return "ip:" + strings.TrimSpace(ip)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
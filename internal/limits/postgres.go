@@ -0,0 +1,99 @@
+package limits
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// PostgresStore keeps the buckets in the rate_limit_buckets table, so that
+// every replica enforces the same quotas. Time is taken from the database to
+// keep replica clocks out of it.
+type PostgresStore struct {
+	db     *sql.DB
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresStore(db *sql.DB, logger *slog.Logger) *PostgresStore {
+	return &PostgresStore{db: db, logger: logger, lastSweep: time.Now()}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, quota Quota) (time.Duration, error) {
+	s.sweep(ctx)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rate_limit_buckets (bucket_key, tokens, updated_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (bucket_key) DO NOTHING
+	`, key, quota.Burst)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var tokens, elapsed float64
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT tokens, EXTRACT(EPOCH FROM now() - updated_at)
+		FROM rate_limit_buckets
+		WHERE bucket_key = $1
+		FOR UPDATE
+	`, key).Scan(&tokens, &elapsed)
+
+	if err != nil {
+		return 0, err
+	}
+
+	tokens, wait := take(tokens, time.Duration(elapsed*float64(time.Second)), quota)
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE rate_limit_buckets SET tokens = $2, updated_at = now() WHERE bucket_key = $1",
+		key, tokens,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return wait, tx.Commit()
+}
+
+// sweep drops idle buckets, at most once per idleBucketTTL per replica.
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	due := time.Since(s.lastSweep) > idleBucketTTL
+	if due {
+		s.lastSweep = time.Now()
+	}
+	s.mu.Unlock()
+
+	if !due {
+		return
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM rate_limit_buckets WHERE updated_at < now() - make_interval(secs => $1)",
+		idleBucketTTL.Seconds(),
+	)
+
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to drop idle rate limit buckets", "error", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package metrics defines the Prometheus collectors of the service. They are
+// registered with the default registry, which /metrics serves.
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "pr_reviewer"
+
+// Triggers label what moved reviews off a reviewer.
+const (
+	TriggerManual       = "manual"
+	TriggerDeactivation = "deactivation"
+	TriggerTeamRemoval  = "team_removal"
+	TriggerTransfer     = "transfer"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	prsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "prs_created_total",
+		Help:      "Pull requests created, drafts included.",
+	})
+
+	prsMerged = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "prs_merged_total",
+		Help:      "Pull requests merged, by whether the merge rules were bypassed.",
+	}, []string{"forced"})
+
+	prsUnderstaffed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "prs_understaffed_total",
+		Help:      "Pull requests opened with fewer reviewers than wanted because not enough were eligible.",
+	})
+
+	reassignments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reassignments_total",
+		Help:      "Reviews moved to another reviewer, by trigger.",
+	}, []string{"trigger"})
+
+	noCandidate = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "no_candidate_total",
+		Help:      "Reviews that could not be moved because nobody was eligible, by trigger.",
+	}, []string{"trigger"})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests refused with 429 because the client was over its quota, by route.",
+	}, []string{"route"})
+)
+
+func init() {
+	for _, trigger := range []string{TriggerManual, TriggerDeactivation, TriggerTeamRemoval, TriggerTransfer} {
+		reassignments.WithLabelValues(trigger)
+		noCandidate.WithLabelValues(trigger)
+	}
+
+	prsMerged.WithLabelValues("false")
+	prsMerged.WithLabelValues("true")
+}
+
+// RegisterDB exports the connection pool statistics of db as
+// go_sql_* series labelled db_name="pr_reviewer".
+func RegisterDB(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// PRCreated counts a new PR.
+func PRCreated() {
+	prsCreated.Inc()
+}
+
+// ReviewersAssigned counts a PR getting fewer than wanted reviewers.
+func ReviewersAssigned(assigned, wanted int) {
+	if assigned < wanted {
+		prsUnderstaffed.Inc()
+	}
+}
+
+// PRMerged counts a merge.
+func PRMerged(forced bool) {
+	prsMerged.WithLabelValues(strconv.FormatBool(forced)).Inc()
+}
+
+// Reassigned counts reviews moved to another reviewer.
+func Reassigned(trigger string, count int) {
+	reassignments.WithLabelValues(trigger).Add(float64(count))
+}
+
+// NoCandidate counts reviews left without a replacement reviewer.
+func NoCandidate(trigger string, count int) {
+	noCandidate.WithLabelValues(trigger).Add(float64(count))
+}
+
+// RateLimited counts a request refused because its client was over quota.
+func RateLimited(route string) {
+	rateLimited.WithLabelValues(route).Inc()
+}
@@ -0,0 +1,423 @@
+// Package migrate applies the versioned SQL migrations and records them in
+// the schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockKey identifies the advisory lock held while migrating, so replicas
+// starting at the same time apply every migration exactly once.
+const lockKey int64 = 0x70725f7265766965
+
+var (
+	// ErrNoDown means the migration to revert has no down script.
+	ErrNoDown = errors.New("migration has no down script")
+	// ErrAlreadyTracked means baseline was asked for a database that
+	// already records applied migrations.
+	ErrAlreadyTracked = errors.New("database already tracks applied migrations")
+)
+
+// Migration is one schema version: NNN_name.sql and, optionally,
+// NNN_name.down.sql reverting it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a known migration together with when it was applied, if it was.
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Load reads the migrations in the root of fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	downs := make(map[int]string)
+
+	for _, entry := range entries {
+		file := entry.Name()
+
+		if entry.IsDir() || path.Ext(file) != ".sql" {
+			continue
+		}
+
+		base := strings.TrimSuffix(file, ".sql")
+		isDown := strings.HasSuffix(base, ".down")
+		base = strings.TrimSuffix(base, ".down")
+
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: name must look like NNN_description.sql", file)
+		}
+
+		body, err := fs.ReadFile(fsys, file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if isDown {
+			downs[version] = string(body)
+			continue
+		}
+
+		if existing, ok := byVersion[version]; ok {
+			return nil, fmt.Errorf("migration %s: version %d is already used by %s", file, version, existing.Name)
+		}
+
+		byVersion[version] = &Migration{Version: version, Name: name, Up: string(body)}
+	}
+
+	for version, down := range downs {
+		m, ok := byVersion[version]
+
+		if !ok {
+			return nil, fmt.Errorf("down script for version %d has no matching migration", version)
+		}
+
+		m.Down = down
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+func New(db *sql.DB, fsys fs.FS, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations, logger: logger}, nil
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction, and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied := []Migration{}
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			err := m.inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+
+				_, err := tx.ExecContext(ctx,
+					"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+					migration.Version, migration.Name,
+				)
+
+				return err
+			})
+
+			if err != nil {
+				return fmt.Errorf("migration %03d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the most recently applied migration. It returns nil if no
+// migration is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var version int
+
+		err := conn.QueryRowContext(ctx, "SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1").Scan(&version)
+
+		if err == sql.ErrNoRows {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		migration, ok := m.find(version)
+
+		if !ok {
+			return fmt.Errorf("applied version %d is unknown to this binary", version)
+		}
+
+		if migration.Down == "" {
+			return fmt.Errorf("migration %03d_%s: %w", migration.Version, migration.Name, ErrNoDown)
+		}
+
+		err = m.inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return err
+			}
+
+			_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", version)
+
+			return err
+		})
+
+		if err != nil {
+			return fmt.Errorf("migration %03d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		reverted = &migration
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status lists every known migration and when it was applied. Versions
+// recorded in the database but unknown to this binary are listed too.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+
+			if appliedAt, ok := done[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+				delete(done, migration.Version)
+			}
+
+			statuses = append(statuses, status)
+		}
+
+		for version, appliedAt := range done {
+			statuses = append(statuses, Status{Version: version, AppliedAt: &appliedAt})
+		}
+
+		sort.Slice(statuses, func(i, j int) bool {
+			return statuses[i].Version < statuses[j].Version
+		})
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// Baseline records migrations up to and including version as applied
+// without running them. It is meant for databases whose schema was created
+// before migrations were tracked, and returns ErrAlreadyTracked otherwise.
+func (m *Migrator) Baseline(ctx context.Context, version int) error {
+	if _, ok := m.find(version); !ok {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		if len(done) > 0 {
+			return ErrAlreadyTracked
+		}
+
+		return m.inTx(ctx, conn, func(tx *sql.Tx) error {
+			for _, migration := range m.migrations {
+				if migration.Version > version {
+					break
+				}
+
+				_, err := tx.ExecContext(ctx,
+					"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+					migration.Version, migration.Name,
+				)
+
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
+
+// Pending returns the known migrations that are not applied yet. Unlike the
+// other methods it does not take the migration lock, so it is cheap enough
+// for readiness checks; it fails if schema_migrations does not exist.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	done, err := m.appliedVersions(ctx, m.db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := done[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory
+// lock, after making sure the schema_migrations table exists.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := conn.Close(); err != nil {
+			m.logger.ErrorContext(ctx, "migration connection close failed", "error", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			m.logger.ErrorContext(ctx, "failed to release migration lock", "error", err)
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// querier is what appliedVersions needs from *sql.DB and *sql.Conn.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, q querier) (map[int]time.Time, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			m.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			m.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
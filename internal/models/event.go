@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	EventPRCreated          = "PR_CREATED"
+	EventStatusChanged      = "STATUS_CHANGED"
+	EventReviewerAssigned   = "REVIEWER_ASSIGNED"
+	EventReviewerUnassigned = "REVIEWER_UNASSIGNED"
+	EventReviewSubmitted    = "REVIEW_SUBMITTED"
+	EventUserActivated      = "USER_ACTIVATED"
+	EventUserDeactivated    = "USER_DEACTIVATED"
+	EventMemberAdded        = "MEMBER_ADDED"
+	EventMemberRemoved      = "MEMBER_REMOVED"
+	EventMemberTransferred  = "MEMBER_TRANSFERRED"
+	EventTeamRenamed        = "TEAM_RENAMED"
+	EventTeamDeleted        = "TEAM_DELETED"
+)
+
+// PREvent is an entry of the append-only audit log. User events such as
+// deactivation have no PullRequestID; membership and team events set
+// FromTeam/ToTeam. Team names are logged as they were at the time, a rename
+// is itself logged as TEAM_RENAMED.
+type PREvent struct {
+	EventID       int64      `json:"event_id"`
+	PullRequestID string     `json:"pull_request_id,omitempty"`
+	EventType     string     `json:"event_type"`
+	UserID        string     `json:"user_id,omitempty"`
+	FromStatus    string     `json:"from_status,omitempty"`
+	ToStatus      string     `json:"to_status,omitempty"`
+	FromTeam      string     `json:"from_team,omitempty"`
+	ToTeam        string     `json:"to_team,omitempty"`
+	Actor         string     `json:"actor,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+}
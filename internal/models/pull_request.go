@@ -2,14 +2,32 @@ package models
 
 import "time"
 
+const (
+	PRStatusDraft  = "DRAFT"
+	PRStatusOpen   = "OPEN"
+	PRStatusClosed = "CLOSED"
+	PRStatusMerged = "MERGED"
+)
+
 type PullRequest struct {
-	PullRequestID     string     `json:"pull_request_id"`
-	PullRequestName   string     `json:"pull_request_name"`
-	AuthorID          string     `json:"author_id"`
-	Status            string     `json:"status"`
-	AssignedReviewers []string   `json:"assigned_reviewers"`
-	CreatedAt         *time.Time `json:"createdAt,omitempty"`
-	MergedAt          *time.Time `json:"mergedAt,omitempty"`
+	PullRequestID     string   `json:"pull_request_id"`
+	PullRequestName   string   `json:"pull_request_name"`
+	AuthorID          string   `json:"author_id"`
+	Status            string   `json:"status"`
+	AssignedReviewers []string `json:"assigned_reviewers"`
+	// FallbackReviewers maps reviewers borrowed from a backup team to that team.
+	FallbackReviewers map[string]string `json:"fallback_reviewers,omitempty"`
+	// AssignedBy maps reviewers to whoever made the request that assigned
+	// them, when known.
+	AssignedBy       map[string]string `json:"assigned_by,omitempty"`
+	Reviews          []Review          `json:"reviews"`
+	CreatedAt        *time.Time        `json:"createdAt,omitempty"`
+	MergedAt         *time.Time        `json:"mergedAt,omitempty"`
+	ClosedAt         *time.Time        `json:"closedAt,omitempty"`
+	ForceMerged      bool              `json:"force_merged,omitempty"`
+	ForceMergeReason string            `json:"force_merge_reason,omitempty"`
+	CreatedBy        string            `json:"created_by,omitempty"`
+	MergedBy         string            `json:"merged_by,omitempty"`
 }
 
 type PullRequestShort struct {
@@ -0,0 +1,47 @@
+package models
+
+// ReviewReassignment describes moving one review off a reviewer. An empty
+// NewReviewerID means nobody was eligible and the review was only removed.
+type ReviewReassignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id,omitempty"`
+	FallbackTeam  string `json:"fallback_team,omitempty"`
+}
+
+// ReviewRebalance describes how the OPEN reviews of users leaving a team's
+// reviewer pool were spread over the remaining reviewers.
+type ReviewRebalance struct {
+	Reassigned           []ReviewReassignment `json:"reassigned"`
+	NoCandidate          []ReviewReassignment `json:"no_candidate"`
+	LeftWithoutReviewers []string             `json:"left_without_reviewers"`
+}
+
+// DeactivationResult keeps the user at the top level, as activating does, with
+// what happened to their reviews alongside.
+type DeactivationResult struct {
+	User      *User           `json:"user"`
+	Rebalance ReviewRebalance `json:"review_rebalance"`
+}
+
+type TeamDeactivationResult struct {
+	TeamName    string   `json:"team_name"`
+	Deactivated []string `json:"deactivated"`
+	ReviewRebalance
+}
+
+type MemberRemovalResult struct {
+	TeamName string   `json:"team_name"`
+	Removed  []string `json:"removed"`
+	ReviewRebalance
+}
+
+// TransferResult reports a move between teams. AuthoredPRs are the user's
+// DRAFT and OPEN PRs, which follow the user to the new team.
+type TransferResult struct {
+	User        *User    `json:"user"`
+	FromTeam    string   `json:"from_team"`
+	ToTeam      string   `json:"to_team"`
+	AuthoredPRs []string `json:"authored_prs"`
+	ReviewRebalance
+}
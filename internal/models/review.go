@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+)
+
+type Review struct {
+	ReviewerID  string     `json:"reviewer_id"`
+	Decision    string     `json:"decision"`
+	Body        string     `json:"body,omitempty"`
+	SubmittedAt *time.Time `json:"submittedAt,omitempty"`
+}
+
+type MergeRuleViolation struct {
+	Rule      string   `json:"rule"`
+	Message   string   `json:"message"`
+	Reviewers []string `json:"reviewers,omitempty"`
+}
@@ -1,12 +1,73 @@
 package models
 
+import "encoding/json"
+
 type Team struct {
-	TeamName string       `json:"team_name"`
-	Members  []TeamMember `json:"members"`
+	TeamName                string       `json:"team_name"`
+	ReviewerStrategy        string       `json:"reviewer_strategy,omitempty"`
+	MinReviewers            int          `json:"min_reviewers"`
+	MaxReviewers            int          `json:"max_reviewers"`
+	RequiredApprovals       int          `json:"required_approvals"`
+	BlockOnChangesRequested bool         `json:"block_on_changes_requested"`
+	RequireAllReviewers     bool         `json:"require_all_reviewers"`
+	BackupTeams             []string     `json:"backup_teams,omitempty"`
+	Members                 []TeamMember `json:"members"`
+	CreatedBy               string       `json:"created_by,omitempty"`
 }
 
 type TeamMember struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID       string `json:"user_id"`
+	Username     string `json:"username"`
+	IsActive     bool   `json:"is_active"`
+	ReviewWeight int    `json:"review_weight"`
+}
+
+// DefaultReviewWeight is the review_weight of members added without one.
+const DefaultReviewWeight = 1
+
+// UnmarshalJSON defaults review_weight only when it is absent: an explicit 0
+// keeps the member out of weighted selection while others are available.
+func (m *TeamMember) UnmarshalJSON(data []byte) error {
+	type plain TeamMember
+	member := plain{ReviewWeight: DefaultReviewWeight}
+
+	if err := json.Unmarshal(data, &member); err != nil {
+		return err
+	}
+
+	*m = TeamMember(member)
+
+	return nil
+}
+
+type TeamSettings struct {
+	TeamName                string   `json:"team_name"`
+	ReviewerStrategy        string   `json:"reviewer_strategy"`
+	MinReviewers            int      `json:"min_reviewers"`
+	MaxReviewers            int      `json:"max_reviewers"`
+	RequiredApprovals       int      `json:"required_approvals"`
+	BlockOnChangesRequested bool     `json:"block_on_changes_requested"`
+	RequireAllReviewers     bool     `json:"require_all_reviewers"`
+	BackupTeams             []string `json:"backup_teams"`
+	CreatedBy               string   `json:"created_by,omitempty"`
+}
+
+// TeamSettingsUpdate is a partial update: nil fields keep their current value.
+type TeamSettingsUpdate struct {
+	TeamName                string    `json:"team_name"`
+	ReviewerStrategy        *string   `json:"reviewer_strategy"`
+	MinReviewers            *int      `json:"min_reviewers"`
+	MaxReviewers            *int      `json:"max_reviewers"`
+	RequiredApprovals       *int      `json:"required_approvals"`
+	BlockOnChangesRequested *bool     `json:"block_on_changes_requested"`
+	RequireAllReviewers     *bool     `json:"require_all_reviewers"`
+	BackupTeams             *[]string `json:"backup_teams"`
+}
+
+// TeamDeletionResult lists the users that belonged to a deleted team. They
+// were moved to TargetTeam, or removed from any team if it is empty.
+type TeamDeletionResult struct {
+	TeamName   string   `json:"team_name"`
+	TargetTeam string   `json:"target_team,omitempty"`
+	Members    []string `json:"members"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// APIToken is a bearer token as stored. The secret itself is never kept;
+// Token is only filled in the response that creates it.
+type APIToken struct {
+	TokenID   int64      `json:"token_id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	TeamName  string     `json:"team_name,omitempty"`
+	UserID    string     `json:"user_id,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	Token     string     `json:"token,omitempty"`
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+)
+
+// The route a request comes through already checked the caller's role; the
+// checks here are about which team or pull request the caller may act on.
+// Without a principal in the context, that is with authentication disabled
+// or when called from the CLI, everything is allowed.
+
+// authorizeTeam lets admins act on any team and team leads on their own.
+func authorizeTeam(ctx context.Context, teamName string) error {
+	p := auth.FromContext(ctx)
+
+	if p == nil || p.Role == auth.RoleAdmin {
+		return nil
+	}
+
+	if p.Role == auth.RoleTeamLead && teamName != "" && p.TeamName == teamName {
+		return nil
+	}
+
+	return ErrForbidden.WithMessage("not allowed to act on team %q", teamName)
+}
+
+// authorizePR lets team leads act on the PRs authored in their team and
+// members on the PRs they author or review. A PR being created has no
+// reviewers yet, so members may only create PRs as themselves.
+func authorizePR(ctx context.Context, pr *models.PullRequest, authorTeam string) error {
+	p := auth.FromContext(ctx)
+
+	if p == nil || p.Role == auth.RoleAdmin {
+		return nil
+	}
+
+	switch p.Role {
+	case auth.RoleTeamLead:
+		if authorTeam != "" && p.TeamName == authorTeam {
+			return nil
+		}
+	case auth.RoleMember:
+		if p.UserID == pr.AuthorID || slices.Contains(pr.AssignedReviewers, p.UserID) {
+			return nil
+		}
+	}
+
+	return ErrForbidden.WithMessage("not allowed to act on PR %s", pr.PullRequestID)
+}
+
+// authorizeMerge is authorizePR, except that only admins may force a merge.
+func authorizeMerge(ctx context.Context, pr *models.PullRequest, authorTeam string, force bool) error {
+	if p := auth.FromContext(ctx); force && p != nil && p.Role != auth.RoleAdmin {
+		return ErrForbidden.WithMessage("only admins may force merge")
+	}
+
+	return authorizePR(ctx, pr, authorTeam)
+}
+
+// authorizeReview lets members review only as themselves and team leads on
+// behalf of the reviewers in their team. Bots never review.
+func authorizeReview(ctx context.Context, reviewerID, reviewerTeam string) error {
+	p := auth.FromContext(ctx)
+
+	if p == nil || p.Role == auth.RoleAdmin {
+		return nil
+	}
+
+	switch p.Role {
+	case auth.RoleTeamLead:
+		if reviewerTeam != "" && p.TeamName == reviewerTeam {
+			return nil
+		}
+	case auth.RoleMember:
+		if p.UserID == reviewerID {
+			return nil
+		}
+	}
+
+	return ErrForbidden.WithMessage("not allowed to review as %s", reviewerID)
+}
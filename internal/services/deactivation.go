@@ -0,0 +1,438 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/metrics"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// errReviewsChanged means a review moved while a deactivation was being
+// planned, so the precomputed reassignments no longer apply.
+var errReviewsChanged = ErrStatusConflict.WithMessage("reviews changed concurrently, retry")
+
+// DeactivateUser marks the user inactive and moves every OPEN review they hold
+// to an eligible reviewer, following the same rules as ReassignReviewer. When
+// nobody is eligible the review is removed and reported under NoCandidate.
+// The flag change and all review changes are committed together.
+func (ps *PRService) DeactivateUser(ctx context.Context, userID string) (_ *models.DeactivationResult, err error) {
+	ctx, span := startSpan(ctx, "PRService.DeactivateUser", attribute.String("user.id", userID))
+	defer func() { endSpan(span, err) }()
+
+	user, err := ps.userService.GetUser(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeTeam(ctx, user.TeamName); err != nil {
+		return nil, err
+	}
+
+	changes, rebalance, err := ps.handOverReviews(ctx, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.DeactivationResult{Rebalance: *rebalance}
+	result.User, err = ps.storage.UpdateUserActiveWithReassignments(ctx, userID, false, changes)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, storage.ErrStatusConflict), errors.Is(err, storage.ErrNotAssigned):
+			return nil, errReviewsChanged
+		}
+
+		return nil, err
+	}
+
+	ps.countRebalance(ctx, metrics.TriggerDeactivation, rebalance)
+
+	return result, nil
+}
+
+// handOverReviews plans moving every OPEN review the user holds to an
+// eligible reviewer, one PR at a time, following the same rules as
+// ReassignReviewer. Nothing is written.
+func (ps *PRService) handOverReviews(ctx context.Context, user *models.User) ([]models.ReviewReassignment, *models.ReviewRebalance, error) {
+	prIDs, err := ps.storage.GetOpenReviewsByUser(ctx, user.UserID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rebalance := newReviewRebalance()
+	changes := make([]models.ReviewReassignment, 0, len(prIDs))
+
+	for _, prID := range prIDs {
+		pr, err := ps.storage.GetPR(ctx, prID)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		newReviewerID, fallbackTeam, err := ps.findReplacement(ctx, pr, user.TeamName)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		change := models.ReviewReassignment{
+			PullRequestID: prID,
+			OldReviewerID: user.UserID,
+			NewReviewerID: newReviewerID,
+			FallbackTeam:  fallbackTeam,
+		}
+		changes = append(changes, change)
+
+		if newReviewerID != "" {
+			rebalance.Reassigned = append(rebalance.Reassigned, change)
+			continue
+		}
+
+		rebalance.NoCandidate = append(rebalance.NoCandidate, change)
+
+		if len(pr.AssignedReviewers) == 1 {
+			rebalance.LeftWithoutReviewers = append(rebalance.LeftWithoutReviewers, prID)
+		}
+	}
+
+	return changes, rebalance, nil
+}
+
+// countRebalance records the outcome of a committed rebalance.
+func (ps *PRService) countRebalance(ctx context.Context, trigger string, rebalance *models.ReviewRebalance) {
+	metrics.Reassigned(trigger, len(rebalance.Reassigned))
+	metrics.NoCandidate(trigger, len(rebalance.NoCandidate))
+
+	level := slog.LevelInfo
+	if len(rebalance.NoCandidate) > 0 {
+		level = slog.LevelWarn
+	}
+
+	ps.logger.Log(ctx, level, "reviews rebalanced",
+		"trigger", trigger,
+		"reassigned", len(rebalance.Reassigned),
+		"no_candidate", len(rebalance.NoCandidate),
+		"left_without_reviewers", rebalance.LeftWithoutReviewers,
+	)
+}
+
+func newReviewRebalance() *models.ReviewRebalance {
+	return &models.ReviewRebalance{
+		Reassigned:           []models.ReviewReassignment{},
+		NoCandidate:          []models.ReviewReassignment{},
+		LeftWithoutReviewers: []string{},
+	}
+}
+
+// reviewerPool is the set of active members of one team that can absorb
+// reviews during a bulk deactivation, with their current open review load.
+type reviewerPool struct {
+	teamName string
+	members  []string
+	load     map[string]int
+}
+
+// DeactivateTeamMembers deactivates several members of a team at once and
+// spreads their OPEN reviews over the remaining active members, least loaded
+// first, falling back to the author's team and its backup teams as
+// ReassignReviewer does. Everything is read up front and written in a single
+// transaction.
+func (ps *PRService) DeactivateTeamMembers(ctx context.Context, teamName string, userIDs []string) (_ *models.TeamDeactivationResult, err error) {
+	ctx, span := startSpan(ctx, "PRService.DeactivateTeamMembers", attribute.String("team.name", teamName))
+	defer func() { endSpan(span, err) }()
+
+	if err := authorizeTeam(ctx, teamName); err != nil {
+		return nil, err
+	}
+
+	settings, ids, err := ps.teamMembersByID(ctx, teamName, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	changes, rebalance, err := ps.rebalanceReviews(ctx, settings, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ps.storage.DeactivateUsersWithReassignments(ctx, ids, changes); err != nil {
+		if errors.Is(err, storage.ErrStatusConflict) || errors.Is(err, storage.ErrNotAssigned) {
+			return nil, errReviewsChanged
+		}
+
+		return nil, err
+	}
+
+	ps.countRebalance(ctx, metrics.TriggerDeactivation, rebalance)
+
+	return &models.TeamDeactivationResult{
+		TeamName:        teamName,
+		Deactivated:     ids,
+		ReviewRebalance: *rebalance,
+	}, nil
+}
+
+// teamMembersByID loads the team and checks that every user is one of its
+// members. The ids are returned deduplicated, in request order.
+func (ps *PRService) teamMembersByID(ctx context.Context, teamName string, userIDs []string) (*models.TeamSettings, []string, error) {
+	if teamName == "" {
+		return nil, nil, invalid("team_name cannot be empty")
+	}
+
+	if len(userIDs) == 0 {
+		return nil, nil, invalid("user_ids cannot be empty")
+	}
+
+	settings, err := ps.storage.GetTeamSettings(ctx, teamName)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrTeamNotFound
+		}
+
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool, len(userIDs))
+	ids := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	users, err := ps.storage.GetUsersByIDs(ctx, ids)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	members := make(map[string]bool, len(users))
+	for _, user := range users {
+		if user.TeamName == teamName {
+			members[user.UserID] = true
+		}
+	}
+
+	for _, id := range ids {
+		if !members[id] {
+			return nil, nil, invalid("user %s is not a member of team %s", id, teamName)
+		}
+	}
+
+	return settings, ids, nil
+}
+
+// rebalanceReviews plans moving the OPEN reviews of the leaving users to the
+// least loaded remaining member of the same teams ReassignReviewer draws
+// from, in the same order: the team, the author's team, then the author's
+// backup teams. Nothing is written.
+func (ps *PRService) rebalanceReviews(ctx context.Context, settings *models.TeamSettings, leaving []string) ([]models.ReviewReassignment, *models.ReviewRebalance, error) {
+	prs, err := ps.storage.GetOpenPRsReviewedBy(ctx, leaving)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authorIDs := make([]string, 0, len(prs))
+	for _, pr := range prs {
+		authorIDs = append(authorIDs, pr.AuthorID)
+	}
+
+	authors, err := ps.storage.GetUsersByIDs(ctx, authorIDs)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authorTeams := make(map[string]string, len(authors))
+	for _, author := range authors {
+		authorTeams[author.UserID] = author.TeamName
+	}
+
+	pools := newReviewerPools(ps, settings, leaving)
+
+	isLeaving := make(map[string]bool, len(leaving))
+	for _, id := range leaving {
+		isLeaving[id] = true
+	}
+
+	rebalance := newReviewRebalance()
+	changes := []models.ReviewReassignment{}
+
+	for _, pr := range prs {
+		assigned := make(map[string]bool, len(pr.AssignedReviewers))
+		for _, id := range pr.AssignedReviewers {
+			assigned[id] = true
+		}
+
+		for _, oldReviewerID := range pr.AssignedReviewers {
+			if !isLeaving[oldReviewerID] {
+				continue
+			}
+
+			delete(assigned, oldReviewerID)
+			change := models.ReviewReassignment{PullRequestID: pr.PullRequestID, OldReviewerID: oldReviewerID}
+
+			authorTeam := authorTeams[pr.AuthorID]
+			candidates, err := pools.forAuthorTeam(ctx, authorTeam)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if pool, newReviewerID := pickLeastLoaded(candidates, pr.AuthorID, assigned); newReviewerID != "" {
+				pool.load[newReviewerID]++
+				assigned[newReviewerID] = true
+				change.NewReviewerID = newReviewerID
+
+				if pool.teamName != authorTeam {
+					change.FallbackTeam = pool.teamName
+				}
+
+				rebalance.Reassigned = append(rebalance.Reassigned, change)
+			} else {
+				rebalance.NoCandidate = append(rebalance.NoCandidate, change)
+			}
+
+			changes = append(changes, change)
+		}
+
+		if len(assigned) == 0 {
+			rebalance.LeftWithoutReviewers = append(rebalance.LeftWithoutReviewers, pr.PullRequestID)
+		}
+	}
+
+	return changes, rebalance, nil
+}
+
+// reviewerPools loads the pools of a rebalance as they are needed, without
+// the leaving users. Each team is loaded once, so that the load counted for
+// one PR carries over to the next.
+type reviewerPools struct {
+	ps       *PRService
+	settings *models.TeamSettings
+	exclude  []string
+	teams    map[string]*reviewerPool
+	ordered  map[string][]*reviewerPool
+}
+
+func newReviewerPools(ps *PRService, settings *models.TeamSettings, exclude []string) *reviewerPools {
+	return &reviewerPools{
+		ps:       ps,
+		settings: settings,
+		exclude:  exclude,
+		teams:    make(map[string]*reviewerPool),
+		ordered:  make(map[string][]*reviewerPool),
+	}
+}
+
+// forAuthorTeam returns the pools for a PR by an author of authorTeam, in the
+// order of replacementTeams: the team being left, the author's team, then
+// the author's backup teams.
+func (rp *reviewerPools) forAuthorTeam(ctx context.Context, authorTeam string) ([]*reviewerPool, error) {
+	if pools, ok := rp.ordered[authorTeam]; ok {
+		return pools, nil
+	}
+
+	names := []string{rp.settings.TeamName}
+
+	if authorTeam != "" {
+		authorSettings := rp.settings
+
+		if authorTeam != rp.settings.TeamName {
+			var err error
+			authorSettings, err = rp.ps.storage.GetTeamSettings(ctx, authorTeam)
+
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return nil, err
+			}
+		}
+
+		names = append(names, authorTeam)
+
+		if authorSettings != nil {
+			names = append(names, rp.ps.backupTeams(authorSettings)...)
+		}
+	}
+
+	seen := make(map[string]bool, len(names))
+	pools := make([]*reviewerPool, 0, len(names))
+
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		pool, err := rp.team(ctx, name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pools = append(pools, pool)
+	}
+
+	rp.ordered[authorTeam] = pools
+
+	return pools, nil
+}
+
+func (rp *reviewerPools) team(ctx context.Context, teamName string) (*reviewerPool, error) {
+	if pool, ok := rp.teams[teamName]; ok {
+		return pool, nil
+	}
+
+	members, err := rp.ps.storage.GetActiveTeamMembers(ctx, teamName, "", rp.exclude)
+
+	if err != nil {
+		return nil, err
+	}
+
+	load, err := rp.ps.storage.GetOpenReviewLoad(ctx, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &reviewerPool{teamName: teamName, members: shuffle(members), load: load}
+	rp.teams[teamName] = pool
+
+	return pool, nil
+}
+
+// pickLeastLoaded returns the least loaded eligible member of the first pool
+// that has one. Members are pre-shuffled, so ties are broken at random.
+func pickLeastLoaded(pools []*reviewerPool, authorID string, assigned map[string]bool) (*reviewerPool, string) {
+	for _, pool := range pools {
+		best := ""
+		for _, id := range pool.members {
+			if id == authorID || assigned[id] {
+				continue
+			}
+
+			if best == "" || pool.load[id] < pool.load[best] {
+				best = id
+			}
+		}
+
+		if best != "" {
+			return pool, best
+		}
+	}
+
+	return nil, ""
+}
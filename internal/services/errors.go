@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+)
+
+// DomainError is an error the API reports to clients. Code and Status are
+// what the handlers put on the wire; Details is optional structured context.
+//
+// The package-level Err* values are sentinels. Services return them as-is
+// or derive a more specific error with WithMessage or WithDetails; derived
+// errors still match their sentinel with errors.Is.
+type DomainError struct {
+	Code    string
+	Status  int
+	Message string
+	Details interface{}
+
+	sentinel *DomainError
+}
+
+var (
+	ErrBadRequest         = newDomainError(http.StatusBadRequest, "BAD_REQUEST", "invalid request")
+	ErrTeamExists         = newDomainError(http.StatusBadRequest, "TEAM_EXISTS", "team_name already exists")
+	ErrTeamNotFound       = newDomainError(http.StatusNotFound, "NOT_FOUND", "team not found")
+	ErrUserNotFound       = newDomainError(http.StatusNotFound, "USER_NOT_FOUND", "user not found")
+	ErrUserInactive       = newDomainError(http.StatusConflict, "USER_INACTIVE", "user is not active")
+	ErrAuthorNotFound     = newDomainError(http.StatusNotFound, "NOT_FOUND", "author not found")
+	ErrPRNotFound         = newDomainError(http.StatusNotFound, "NOT_FOUND", "PR not found")
+	ErrPRExists           = newDomainError(http.StatusConflict, "PR_EXISTS", "PR id already exists")
+	ErrPRDraft            = newDomainError(http.StatusConflict, "PR_DRAFT", "PR is a draft")
+	ErrPROpen             = newDomainError(http.StatusConflict, "PR_OPEN", "PR is already open")
+	ErrPRClosed           = newDomainError(http.StatusConflict, "PR_CLOSED", "PR is closed")
+	ErrPRMerged           = newDomainError(http.StatusConflict, "PR_MERGED", "PR is already merged")
+	ErrStatusConflict     = newDomainError(http.StatusConflict, "STATUS_CONFLICT", "PR status changed concurrently")
+	ErrNotAssigned        = newDomainError(http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+	ErrNoCandidate        = newDomainError(http.StatusConflict, "NO_CANDIDATE", "no active replacement candidate in team")
+	ErrNotEnoughReviewers = newDomainError(http.StatusConflict, "NOT_ENOUGH_REVIEWERS", "team cannot supply the minimum number of reviewers")
+	ErrMergeBlocked       = newDomainError(http.StatusConflict, "MERGE_BLOCKED", "merge rules are not satisfied")
+	ErrForceMergeDisabled = newDomainError(http.StatusForbidden, "FORCE_MERGE_DISABLED", "force merge is disabled on this server")
+	ErrMemberExists       = newDomainError(http.StatusConflict, "MEMBER_EXISTS", "user already belongs to a team")
+	ErrMemberHasOpenPRs   = newDomainError(http.StatusConflict, "MEMBER_HAS_OPEN_PRS", "user still authors DRAFT or OPEN PRs")
+	ErrTeamHasOpenPRs     = newDomainError(http.StatusConflict, "TEAM_HAS_OPEN_PRS", "team members still author or review active PRs")
+	ErrUnauthorized       = newDomainError(http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid API token")
+	ErrForbidden          = newDomainError(http.StatusForbidden, "FORBIDDEN", "not allowed")
+	ErrTokenNotFound      = newDomainError(http.StatusNotFound, "NOT_FOUND", "token not found")
+)
+
+func newDomainError(status int, code, message string) *DomainError {
+	return &DomainError{Code: code, Status: status, Message: message}
+}
+
+func (e *DomainError) Error() string {
+	return e.Message
+}
+
+// Is matches e against the sentinel it was derived from.
+func (e *DomainError) Is(target error) bool {
+	t, ok := target.(*DomainError)
+
+	return ok && (t == e || t == e.sentinel)
+}
+
+func (e *DomainError) derive() *DomainError {
+	derived := *e
+	if e.sentinel == nil {
+		derived.sentinel = e
+	}
+
+	return &derived
+}
+
+// WithMessage returns a copy of e with a more specific message.
+func (e *DomainError) WithMessage(format string, args ...interface{}) *DomainError {
+	derived := e.derive()
+	derived.Message = fmt.Sprintf(format, args...)
+
+	return derived
+}
+
+// WithDetails returns a copy of e carrying structured details for the client.
+func (e *DomainError) WithDetails(details interface{}) *DomainError {
+	derived := e.derive()
+	derived.Details = details
+
+	return derived
+}
+
+// invalid is shorthand for a BAD_REQUEST error with the given message.
+func invalid(format string, args ...interface{}) *DomainError {
+	return ErrBadRequest.WithMessage(format, args...)
+}
+
+// prStatusError reports that an operation is not allowed in the PR's current
+// status.
+func prStatusError(status string) *DomainError {
+	switch status {
+	case models.PRStatusDraft:
+		return ErrPRDraft
+	case models.PRStatusOpen:
+		return ErrPROpen
+	case models.PRStatusClosed:
+		return ErrPRClosed
+	case models.PRStatusMerged:
+		return ErrPRMerged
+	}
+
+	return ErrStatusConflict
+}
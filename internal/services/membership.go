@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/metrics"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// errMembershipChanged means a user or review moved while a membership change
+// was being planned, so the precomputed reassignments no longer apply.
+var errMembershipChanged = ErrStatusConflict.WithMessage("team membership or reviews changed concurrently, retry")
+
+// RemoveTeamMembers takes users out of the team. Removed users are
+// deactivated and keep no team; their OPEN reviews are spread over the
+// remaining members like in DeactivateTeamMembers. Users who still author
+// DRAFT or OPEN PRs cannot be removed, since those PRs would be left without
+// a team to take reviewers and merge rules from: they have to be closed,
+// merged or the author transferred instead.
+func (ps *PRService) RemoveTeamMembers(ctx context.Context, teamName string, userIDs []string) (_ *models.MemberRemovalResult, err error) {
+	ctx, span := startSpan(ctx, "PRService.RemoveTeamMembers", attribute.String("team.name", teamName))
+	defer func() { endSpan(span, err) }()
+
+	if err := authorizeTeam(ctx, teamName); err != nil {
+		return nil, err
+	}
+
+	settings, ids, err := ps.teamMembersByID(ctx, teamName, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	authored, err := ps.storage.GetActivePRsByAuthors(ctx, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(authored) > 0 {
+		return nil, ErrMemberHasOpenPRs.WithDetails(map[string]interface{}{"pull_requests": authored})
+	}
+
+	changes, rebalance, err := ps.rebalanceReviews(ctx, settings, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ps.storage.RemoveTeamMembers(ctx, teamName, ids, changes); err != nil {
+		if errors.Is(err, storage.ErrStatusConflict) || errors.Is(err, storage.ErrNotAssigned) {
+			return nil, errMembershipChanged
+		}
+
+		return nil, err
+	}
+
+	ps.countRebalance(ctx, metrics.TriggerTeamRemoval, rebalance)
+
+	return &models.MemberRemovalResult{
+		TeamName:        teamName,
+		Removed:         ids,
+		ReviewRebalance: *rebalance,
+	}, nil
+}
+
+// TransferUser moves a user to another team.
+//
+// PRs the user authored follow them: from now on their reviewers and merge
+// rules come from the new team, while reviewers already assigned are kept.
+// The OPEN reviews the user holds are handed over like on deactivation,
+// unless keepReviews is set, in which case the user stays on them.
+func (ps *PRService) TransferUser(ctx context.Context, userID, toTeam string, keepReviews bool) (_ *models.TransferResult, err error) {
+	ctx, span := startSpan(ctx, "PRService.TransferUser", attribute.String("user.id", userID), attribute.String("team.name", toTeam))
+	defer func() { endSpan(span, err) }()
+
+	if userID == "" {
+		return nil, invalid("user_id cannot be empty")
+	}
+
+	if toTeam == "" {
+		return nil, invalid("to_team cannot be empty")
+	}
+
+	user, err := ps.userService.GetUser(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TeamName == "" {
+		return nil, invalid("user %s does not belong to a team, add them as a new member instead", userID)
+	}
+
+	if user.TeamName == toTeam {
+		return nil, invalid("user %s is already a member of team %s", userID, toTeam)
+	}
+
+	if _, err := ps.storage.GetTeamSettings(ctx, toTeam); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrTeamNotFound
+		}
+
+		return nil, err
+	}
+
+	changes := []models.ReviewReassignment{}
+	rebalance := newReviewRebalance()
+
+	if !keepReviews {
+		changes, rebalance, err = ps.handOverReviews(ctx, user)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	authored, err := ps.storage.GetActivePRsByAuthors(ctx, []string{userID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	moved, err := ps.storage.TransferUser(ctx, userID, user.TeamName, toTeam, changes)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrStatusConflict) || errors.Is(err, storage.ErrNotAssigned) {
+			return nil, errMembershipChanged
+		}
+
+		return nil, err
+	}
+
+	ps.countRebalance(ctx, metrics.TriggerTransfer, rebalance)
+
+	return &models.TransferResult{
+		User:            moved,
+		FromTeam:        user.TeamName,
+		ToTeam:          toTeam,
+		AuthoredPRs:     authored,
+		ReviewRebalance: *rebalance,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+)
+
+const (
+	RuleMinApprovals          = "min_approvals"
+	RuleNoChangesRequested    = "no_changes_requested"
+	RuleAllReviewersResponded = "all_reviewers_responded"
+)
+
+// evaluateMergeRules checks the PR against the merge rules of the author's
+// team, considering only the latest decision of currently assigned reviewers.
+func evaluateMergeRules(settings *models.TeamSettings, pr *models.PullRequest) []models.MergeRuleViolation {
+	decisions := latestDecisions(pr)
+	violations := []models.MergeRuleViolation{}
+
+	if approvals := countApprovals(pr); approvals < settings.RequiredApprovals {
+		violations = append(violations, models.MergeRuleViolation{
+			Rule:    RuleMinApprovals,
+			Message: fmt.Sprintf("%d of %d required approvals", approvals, settings.RequiredApprovals),
+		})
+	}
+
+	if settings.BlockOnChangesRequested {
+		blocking := []string{}
+		for reviewerID, decision := range decisions {
+			if decision == models.ReviewChangesRequested {
+				blocking = append(blocking, reviewerID)
+			}
+		}
+
+		if len(blocking) > 0 {
+			sort.Strings(blocking)
+			violations = append(violations, models.MergeRuleViolation{
+				Rule:      RuleNoChangesRequested,
+				Message:   "changes requested by assigned reviewers",
+				Reviewers: blocking,
+			})
+		}
+	}
+
+	if settings.RequireAllReviewers {
+		pending := []string{}
+		for _, reviewerID := range pr.AssignedReviewers {
+			if _, responded := decisions[reviewerID]; !responded {
+				pending = append(pending, reviewerID)
+			}
+		}
+
+		if len(pending) > 0 {
+			violations = append(violations, models.MergeRuleViolation{
+				Rule:      RuleAllReviewersResponded,
+				Message:   "assigned reviewers have not responded",
+				Reviewers: pending,
+			})
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,24 @@
+package services
+
+// Options are the deployment-wide settings the services honour. Start from
+// DefaultOptions and override what the configuration sets.
+type Options struct {
+	// ReviewerStrategy and MaxReviewers apply to teams created without
+	// their own values.
+	ReviewerStrategy string
+	MaxReviewers     int
+	// AllowForceMerge lets MergePR bypass unmet merge rules when asked to.
+	AllowForceMerge bool
+	// UseBackupTeams lets reviewers be borrowed from a team's backup teams
+	// when the team itself cannot supply enough of them.
+	UseBackupTeams bool
+}
+
+func DefaultOptions() Options {
+	return Options{
+		ReviewerStrategy: DefaultReviewerStrategy,
+		MaxReviewers:     DefaultMaxReviewers,
+		AllowForceMerge:  true,
+		UseBackupTeams:   true,
+	}
+}
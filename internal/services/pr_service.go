@@ -3,74 +3,208 @@ package services
 import (
 	"context"
 	"errors"
-	"math/rand"
+	"log/slog"
 
+	"github.com/Jersonmade/pr-reviewer-service/internal/metrics"
 	"github.com/Jersonmade/pr-reviewer-service/internal/models"
 	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const DefaultMaxReviewers = 2
+
 type PRService struct {
-	storage     *storage.PostgresStorage
+	storage     storage.Store
 	userService *UserService
+	selectors   map[string]ReviewerSelector
+	options     Options
+	logger      *slog.Logger
 }
 
-func NewPRService(s *storage.PostgresStorage, us *UserService) *PRService {
+func NewPRService(s storage.Store, us *UserService, options Options, logger *slog.Logger) *PRService {
 	return &PRService{
 		storage:     s,
 		userService: us,
+		selectors:   NewReviewerSelectors(s),
+		options:     options,
+		logger:      logger,
 	}
 }
 
-func (ps *PRService) CreatePR(ctx context.Context, prID, prName, authorID string) (*models.PullRequest, error) {
+// backupTeams returns the team's backup teams, or none when borrowing
+// reviewers from backup teams is turned off.
+func (ps *PRService) backupTeams(settings *models.TeamSettings) []string {
+	if !ps.options.UseBackupTeams {
+		return nil
+	}
+
+	return settings.BackupTeams
+}
+
+// CreatePR opens a PR and assigns reviewers from the author's team. A zero
+// reviewersCount means the team's max_reviewers. Drafts get no reviewers
+// until they are marked ready, and then the team's max_reviewers, so they
+// take no reviewersCount.
+func (ps *PRService) CreatePR(ctx context.Context, prID, prName, authorID string, reviewersCount int, draft bool) (_ *models.PullRequest, err error) {
+	ctx, span := startSpan(ctx, "PRService.CreatePR", attribute.String("pr.id", prID), attribute.String("user.id", authorID))
+	defer func() { endSpan(span, err) }()
+
 	if prID == "" {
-		return nil, errors.New("pull_request_id cannot be empty")
+		return nil, invalid("pull_request_id cannot be empty")
 	}
 
 	if prName == "" {
-		return nil, errors.New("pull_request_name cannot be empty")
+		return nil, invalid("pull_request_name cannot be empty")
 	}
 
 	if authorID == "" {
-		return nil, errors.New("author_id cannot be empty")
+		return nil, invalid("author_id cannot be empty")
+	}
+
+	if draft && reviewersCount != 0 {
+		return nil, invalid("reviewers_count cannot be set for a draft")
 	}
 
 	author, err := ps.userService.GetUser(ctx, authorID)
 
 	if err != nil {
-		if err.Error() == "USER_NOT_FOUND" {
-			return nil, errors.New("AUTHOR_NOT_FOUND")
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrAuthorNotFound
 		}
 
 		return nil, err
 	}
 
-	reviewers, err := ps.selectReviewers(ctx, author.TeamName, authorID)
+	pr := &models.PullRequest{
+		PullRequestID:   prID,
+		PullRequestName: prName,
+		AuthorID:        authorID,
+		Status:          models.PRStatusDraft,
+	}
 
-	if err != nil {
+	if err := authorizePR(ctx, pr, author.TeamName); err != nil {
 		return nil, err
 	}
 
-	pr := &models.PullRequest{
-		PullRequestID:     prID,
-		PullRequestName:   prName,
-		AuthorID:          authorID,
-		Status:            "OPEN",
-		AssignedReviewers: reviewers,
+	wanted := 0
+
+	if !draft {
+		reviewers, fallback, count, err := ps.selectReviewers(ctx, author.TeamName, authorID, reviewersCount)
+
+		if err != nil {
+			return nil, err
+		}
+
+		wanted = count
+
+		pr.Status = models.PRStatusOpen
+		pr.AssignedReviewers = reviewers
+		pr.FallbackReviewers = fallback
 	}
 
 	if err := ps.storage.CreatePR(ctx, pr); err != nil {
 		if errors.Is(err, storage.ErrPRExists) {
-			return nil, errors.New("PR_EXISTS")
+			return nil, ErrPRExists
 		}
 
 		return nil, err
 	}
 
+	metrics.PRCreated()
+	ps.logger.InfoContext(ctx, "pull request created",
+		"author_id", authorID, "status", pr.Status, "reviewers", pr.AssignedReviewers)
+
+	if !draft {
+		ps.reviewersAssigned(ctx, pr.AssignedReviewers, wanted)
+	}
+
 	return ps.storage.GetPR(ctx, prID)
 }
 
-func (ps *PRService) selectReviewers(ctx context.Context, teamName, excludeUserID string) ([]string, error) {
-	candidates, err := ps.userService.GetActiveTeamMembers(ctx, teamName, excludeUserID, []string{})
+// reviewersAssigned records a PR getting fewer reviewers than wanted, which
+// means its team and backup teams ran out of eligible reviewers.
+func (ps *PRService) reviewersAssigned(ctx context.Context, reviewers []string, wanted int) {
+	metrics.ReviewersAssigned(len(reviewers), wanted)
+
+	if len(reviewers) < wanted {
+		ps.logger.WarnContext(ctx, "pull request has fewer reviewers than wanted",
+			"reviewers", reviewers, "wanted", wanted)
+	}
+}
+
+// selectReviewers fills the reviewer slots from the author's team first and
+// then from its backup teams in priority order. The returned map tells which
+// reviewers were borrowed from which backup team, and the count how many
+// reviewers were wanted. A PR needs at least min_reviewers, and at least as
+// many reviewers as required_approvals, or it could never be merged.
+func (ps *PRService) selectReviewers(ctx context.Context, teamName, excludeUserID string, count int) ([]string, map[string]string, int, error) {
+	if teamName == "" {
+		return nil, nil, 0, invalid("author %s does not belong to a team", excludeUserID)
+	}
+
+	settings, err := ps.storage.GetTeamSettings(ctx, teamName)
+
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if count == 0 {
+		count = settings.MaxReviewers
+	}
+
+	minimum := max(settings.MinReviewers, settings.RequiredApprovals)
+
+	if count < minimum || count > settings.MaxReviewers {
+		return nil, nil, 0, invalid("reviewers_count must be between %d and %d", minimum, settings.MaxReviewers)
+	}
+
+	reviewers, err := ps.pickFromTeam(ctx, settings, excludeUserID, nil, count)
+
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	fallback := make(map[string]string)
+
+	for _, backup := range ps.backupTeams(settings) {
+		if len(reviewers) >= count {
+			break
+		}
+
+		backupSettings, err := ps.storage.GetTeamSettings(ctx, backup)
+
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+
+			return nil, nil, 0, err
+		}
+
+		picked, err := ps.pickFromTeam(ctx, backupSettings, excludeUserID, reviewers, count-len(reviewers))
+
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		for _, id := range picked {
+			fallback[id] = backup
+		}
+
+		reviewers = append(reviewers, picked...)
+	}
+
+	if len(reviewers) < minimum {
+		return nil, nil, 0, ErrNotEnoughReviewers
+	}
+
+	return reviewers, fallback, count, nil
+}
+
+// pickFromTeam selects up to count active members of the team using the
+// team's own strategy, skipping the author and anyone in exclude.
+func (ps *PRService) pickFromTeam(ctx context.Context, settings *models.TeamSettings, authorID string, exclude []string, count int) ([]string, error) {
+	candidates, err := ps.userService.GetActiveTeamMembers(ctx, settings.TeamName, authorID, exclude)
 
 	if err != nil {
 		return nil, err
@@ -80,28 +214,28 @@ func (ps *PRService) selectReviewers(ctx context.Context, teamName, excludeUserI
 		return []string{}, nil
 	}
 
-	rand.Shuffle(len(candidates), func(i, j int) {
-		candidates[i], candidates[j] = candidates[j], candidates[i]
-	})
+	selector, err := ps.selectorFor(settings)
 
-	max := 2
-	if len(candidates) < max {
-		max = len(candidates)
+	if err != nil {
+		return nil, err
 	}
 
-	return candidates[:max], nil
+	return selector.Select(ctx, settings.TeamName, candidates, count)
 }
 
-func (ps *PRService) GetPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+func (ps *PRService) GetPR(ctx context.Context, prID string) (_ *models.PullRequest, err error) {
+	ctx, span := startSpan(ctx, "PRService.GetPR", attribute.String("pr.id", prID))
+	defer func() { endSpan(span, err) }()
+
 	if prID == "" {
-		return nil, errors.New("pull_request_id cannot be empty")
+		return nil, invalid("pull_request_id cannot be empty")
 	}
 
 	pr, err := ps.storage.GetPR(ctx, prID)
 
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
-			return nil, errors.New("PR_NOT_FOUND")
+			return nil, ErrPRNotFound
 		}
 		return nil, err
 	}
@@ -109,55 +243,357 @@ func (ps *PRService) GetPR(ctx context.Context, prID string) (*models.PullReques
 	return pr, nil
 }
 
-func (ps *PRService) MergePR(ctx context.Context, prID string) (*models.PullRequest, error) {
+// authorizePR checks that the caller may act on the PR, which depends on the
+// author's team.
+func (ps *PRService) authorizePR(ctx context.Context, pr *models.PullRequest) error {
+	author, err := ps.userService.GetUser(ctx, pr.AuthorID)
+
+	if err != nil {
+		return err
+	}
+
+	return authorizePR(ctx, pr, author.TeamName)
+}
+
+// MergePR merges an OPEN PR once the merge rules of the author's team are
+// satisfied. With force unmet rules are bypassed, which needs a reason; only
+// merges that actually bypassed a rule are flagged as forced.
+func (ps *PRService) MergePR(ctx context.Context, prID string, force bool, reason string) (_ *models.PullRequest, err error) {
+	ctx, span := startSpan(ctx, "PRService.MergePR", attribute.String("pr.id", prID), attribute.Bool("pr.force", force))
+	defer func() { endSpan(span, err) }()
+
 	if prID == "" {
-		return nil, errors.New("pull_request_id cannot be empty")
+		return nil, invalid("pull_request_id cannot be empty")
 	}
 
 	pr, err := ps.storage.GetPR(ctx, prID)
 
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
-			return nil, errors.New("PR_NOT_FOUND")
+			return nil, ErrPRNotFound
 		}
 
 		return nil, err
 	}
 
-	if pr.Status == "MERGED" {
+	author, err := ps.userService.GetUser(ctx, pr.AuthorID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeMerge(ctx, pr, author.TeamName, force); err != nil {
+		return nil, err
+	}
+
+	switch pr.Status {
+	case models.PRStatusMerged:
 		return pr, nil
+	case models.PRStatusDraft, models.PRStatusClosed:
+		return nil, prStatusError(pr.Status)
+	}
+
+	if force && !ps.options.AllowForceMerge {
+		return nil, ErrForceMergeDisabled
 	}
 
-	mergedPR, err := ps.storage.MergePR(ctx, prID)
+	settings, err := ps.storage.GetTeamSettings(ctx, author.TeamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The rules are evaluated by the storage on the PR as it is merged, so
+	// reviews and reassignments landing meanwhile are not missed.
+	var violations []models.MergeRuleViolation
+	var bypassed bool
+
+	mergedPR, err := ps.storage.MergePR(ctx, prID, func(pr *models.PullRequest) (bool, string, error) {
+		violations = evaluateMergeRules(settings, pr)
+
+		if len(violations) > 0 && !force {
+			return false, "", ErrMergeBlocked.WithDetails(map[string]interface{}{"unmet_rules": violations})
+		}
+
+		bypassed = force && len(violations) > 0
+
+		if bypassed && reason == "" {
+			return false, "", invalid("reason is required to force merge past unmet rules")
+		}
+
+		if !bypassed {
+			return false, "", nil
+		}
+
+		return true, reason, nil
+	})
 
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
-			return nil, errors.New("PR_NOT_FOUND")
+			return nil, ErrPRNotFound
+		}
+
+		if errors.Is(err, storage.ErrStatusConflict) {
+			return nil, ErrStatusConflict
 		}
 
 		return nil, err
 	}
 
+	metrics.PRMerged(bypassed)
+
+	if bypassed {
+		ps.logger.WarnContext(ctx, "pull request force merged", "unmet_rules", violations, "reason", reason)
+	} else {
+		ps.logger.InfoContext(ctx, "pull request merged")
+	}
+
 	return mergedPR, nil
 }
 
-func (ps *PRService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (string, error) {
+// SubmitReview records an assigned reviewer's decision on an OPEN PR.
+// Reviewers may submit several times; their latest APPROVED or
+// CHANGES_REQUESTED is the one that counts towards merging, which a later
+// COMMENTED does not replace.
+func (ps *PRService) SubmitReview(ctx context.Context, prID string, review *models.Review) (_ *models.PullRequest, err error) {
+	ctx, span := startSpan(ctx, "PRService.SubmitReview", attribute.String("pr.id", prID))
+	defer func() { endSpan(span, err) }()
+
+	if review.ReviewerID == "" {
+		return nil, invalid("reviewer_id cannot be empty")
+	}
+
+	switch review.Decision {
+	case models.ReviewApproved, models.ReviewChangesRequested, models.ReviewCommented:
+	default:
+		return nil, invalid("unknown decision: %s", review.Decision)
+	}
+
+	pr, err := ps.GetPR(ctx, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if pr.Status != models.PRStatusOpen {
+		return nil, prStatusError(pr.Status)
+	}
+
+	if pr.AuthorID == review.ReviewerID {
+		return nil, ErrNotAssigned
+	}
+
+	reviewer, err := ps.userService.GetUser(ctx, review.ReviewerID)
+
+	if err != nil {
+		// Unknown users cannot be assigned either.
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrNotAssigned
+		}
+
+		return nil, err
+	}
+
+	if err := authorizeReview(ctx, review.ReviewerID, reviewer.TeamName); err != nil {
+		return nil, err
+	}
+
+	if err := ps.storage.AddReview(ctx, prID, review); err != nil {
+		if errors.Is(err, storage.ErrNotAssigned) {
+			return nil, ErrNotAssigned
+		}
+
+		if errors.Is(err, storage.ErrStatusConflict) {
+			return nil, ErrStatusConflict
+		}
+
+		return nil, err
+	}
+
+	return ps.storage.GetPR(ctx, prID)
+}
+
+// latestDecisions returns the standing decision of every currently assigned
+// reviewer who has responded: their latest APPROVED or CHANGES_REQUESTED, or
+// COMMENTED if they only commented. A comment does not withdraw a decision.
+func latestDecisions(pr *models.PullRequest) map[string]string {
+	assigned := make(map[string]bool, len(pr.AssignedReviewers))
+	for _, id := range pr.AssignedReviewers {
+		assigned[id] = true
+	}
+
+	decisions := make(map[string]string)
+	for _, review := range pr.Reviews {
+		if !assigned[review.ReviewerID] {
+			continue
+		}
+
+		if _, decided := decisions[review.ReviewerID]; decided && review.Decision == models.ReviewCommented {
+			continue
+		}
+
+		decisions[review.ReviewerID] = review.Decision
+	}
+
+	return decisions
+}
+
+func countApprovals(pr *models.PullRequest) int {
+	approvals := 0
+	for _, decision := range latestDecisions(pr) {
+		if decision == models.ReviewApproved {
+			approvals++
+		}
+	}
+
+	return approvals
+}
+
+// ClosePR closes an OPEN or DRAFT PR without merging. Closing an already
+// CLOSED PR is a no-op.
+func (ps *PRService) ClosePR(ctx context.Context, prID string) (_ *models.PullRequest, err error) {
+	ctx, span := startSpan(ctx, "PRService.ClosePR", attribute.String("pr.id", prID))
+	defer func() { endSpan(span, err) }()
+
+	pr, err := ps.GetPR(ctx, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ps.authorizePR(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	switch pr.Status {
+	case models.PRStatusClosed:
+		return pr, nil
+	case models.PRStatusMerged:
+		return nil, ErrPRMerged
+	}
+
+	closedPR, err := ps.storage.ClosePR(ctx, prID)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrStatusConflict) {
+			return nil, ErrStatusConflict
+		}
+
+		return nil, err
+	}
+
+	return closedPR, nil
+}
+
+// ReopenPR moves a CLOSED PR back to OPEN. Reviewers assigned before closing
+// are kept; a PR that was closed as a draft gets reviewers assigned now.
+func (ps *PRService) ReopenPR(ctx context.Context, prID string) (_ *models.PullRequest, err error) {
+	ctx, span := startSpan(ctx, "PRService.ReopenPR", attribute.String("pr.id", prID))
+	defer func() { endSpan(span, err) }()
+
+	pr, err := ps.GetPR(ctx, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ps.authorizePR(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	if pr.Status != models.PRStatusClosed {
+		return nil, prStatusError(pr.Status)
+	}
+
+	return ps.openPR(ctx, pr)
+}
+
+// MarkReady moves a DRAFT PR to OPEN and assigns its reviewers.
+func (ps *PRService) MarkReady(ctx context.Context, prID string) (_ *models.PullRequest, err error) {
+	ctx, span := startSpan(ctx, "PRService.MarkReady", attribute.String("pr.id", prID))
+	defer func() { endSpan(span, err) }()
+
+	pr, err := ps.GetPR(ctx, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ps.authorizePR(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	if pr.Status != models.PRStatusDraft {
+		return nil, prStatusError(pr.Status)
+	}
+
+	return ps.openPR(ctx, pr)
+}
+
+func (ps *PRService) openPR(ctx context.Context, pr *models.PullRequest) (*models.PullRequest, error) {
+	var reviewers []string
+	var fallback map[string]string
+	wanted := 0
+
+	if len(pr.AssignedReviewers) == 0 {
+		author, err := ps.userService.GetUser(ctx, pr.AuthorID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		reviewers, fallback, wanted, err = ps.selectReviewers(ctx, author.TeamName, pr.AuthorID, 0)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	openedPR, err := ps.storage.OpenPR(ctx, pr.PullRequestID, pr.Status, reviewers, fallback)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrStatusConflict) {
+			return nil, ErrStatusConflict
+		}
+
+		return nil, err
+	}
+
+	ps.logger.InfoContext(ctx, "pull request opened", "from_status", pr.Status, "reviewers", openedPR.AssignedReviewers)
+
+	if wanted > 0 {
+		ps.reviewersAssigned(ctx, reviewers, wanted)
+	}
+
+	return openedPR, nil
+}
+
+// ReassignReviewer replaces oldReviewerID on an OPEN PR. The reason is
+// recorded in the PR history.
+func (ps *PRService) ReassignReviewer(ctx context.Context, prID, oldReviewerID, reason string) (_ string, err error) {
+	ctx, span := startSpan(ctx, "PRService.ReassignReviewer", attribute.String("pr.id", prID), attribute.String("user.id", oldReviewerID))
+	defer func() { endSpan(span, err) }()
+
 	if prID == "" {
-		return "", errors.New("pull_request_id cannot be empty")
+		return "", invalid("pull_request_id cannot be empty")
 	}
 
 	if oldReviewerID == "" {
-		return "", errors.New("old_user_id cannot be empty")
+		return "", invalid("old_user_id cannot be empty")
 	}
 
-	pr, err := ps.storage.GetPR(ctx, prID)
+	pr, err := ps.GetPR(ctx, prID)
 
 	if err != nil {
 		return "", err
 	}
 
-	if pr.Status == "MERGED" {
-		return "", errors.New("PR_MERGED")
+	if err := ps.authorizePR(ctx, pr); err != nil {
+		return "", err
+	}
+
+	if pr.Status != models.PRStatusOpen {
+		return "", prStatusError(pr.Status)
 	}
 
 	found := false
@@ -169,45 +605,140 @@ func (ps *PRService) ReassignReviewer(ctx context.Context, prID, oldReviewerID s
 	}
 
 	if !found {
-		return "", errors.New("NOT_ASSIGNED")
+		return "", ErrNotAssigned
 	}
 
 	oldReviewer, err := ps.userService.GetUser(ctx, oldReviewerID)
 
 	if err != nil {
-		if err.Error() == "USER_NOT_FOUND" {
-			return "", errors.New("USER_NOT_FOUND")
-		}
-
 		return "", err
 	}
 
-	candidates, err := ps.userService.GetActiveTeamMembers(ctx, oldReviewer.TeamName, pr.AuthorID, pr.AssignedReviewers)
+	newReviewerID, fallbackTeam, err := ps.findReplacement(ctx, pr, oldReviewer.TeamName)
 
 	if err != nil {
 		return "", err
 	}
 
-	if len(candidates) == 0 {
-		return "", errors.New("NO_CANDIDATE")
-	}
+	if newReviewerID == "" {
+		metrics.NoCandidate(metrics.TriggerManual, 1)
+		ps.logger.WarnContext(ctx, "no reviewer candidate", "old_reviewer_id", oldReviewerID)
 
-	newReviewerID := candidates[rand.Intn(len(candidates))]
+		return "", ErrNoCandidate
+	}
 
-	if err := ps.storage.ReassignReviewer(ctx, prID, oldReviewerID, newReviewerID); err != nil {
+	if err := ps.storage.ReassignReviewer(ctx, prID, oldReviewerID, newReviewerID, fallbackTeam, reason); err != nil {
 		if errors.Is(err, storage.ErrNotAssigned) {
-			return "", errors.New("NOT_ASSIGNED")
+			return "", ErrNotAssigned
+		}
+
+		if errors.Is(err, storage.ErrStatusConflict) {
+			return "", ErrStatusConflict
 		}
 
 		return "", err
 	}
 
+	metrics.Reassigned(metrics.TriggerManual, 1)
+	ps.logger.InfoContext(ctx, "reviewer reassigned",
+		"old_reviewer_id", oldReviewerID, "new_reviewer_id", newReviewerID, "fallback_team", fallbackTeam)
+
 	return newReviewerID, nil
 }
 
-func (ps *PRService) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+// findReplacement picks one active reviewer for the PR who is neither the
+// author nor already assigned. It returns an empty id when nobody is eligible,
+// and the backup team name when the replacement was borrowed from one.
+func (ps *PRService) findReplacement(ctx context.Context, pr *models.PullRequest, reviewerTeam string) (string, string, error) {
+	teams, authorTeam, err := ps.replacementTeams(ctx, reviewerTeam, pr.AuthorID)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, settings := range teams {
+		picked, err := ps.pickFromTeam(ctx, settings, pr.AuthorID, pr.AssignedReviewers, 1)
+
+		if err != nil {
+			return "", "", err
+		}
+
+		if len(picked) > 0 {
+			if settings.TeamName != authorTeam {
+				return picked[0], settings.TeamName, nil
+			}
+
+			return picked[0], "", nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// replacementTeams lists where a replacement reviewer may come from, in order:
+// the old reviewer's team, the author's team, then the author's backup teams.
+func (ps *PRService) replacementTeams(ctx context.Context, reviewerTeam, authorID string) ([]*models.TeamSettings, string, error) {
+	author, err := ps.userService.GetUser(ctx, authorID)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	authorSettings, err := ps.storage.GetTeamSettings(ctx, author.TeamName)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := append([]string{reviewerTeam, author.TeamName}, ps.backupTeams(authorSettings)...)
+	seen := make(map[string]bool, len(names))
+	teams := make([]*models.TeamSettings, 0, len(names))
+
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if name == author.TeamName {
+			teams = append(teams, authorSettings)
+			continue
+		}
+
+		settings, err := ps.storage.GetTeamSettings(ctx, name)
+
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+
+			return nil, "", err
+		}
+
+		teams = append(teams, settings)
+	}
+
+	return teams, author.TeamName, nil
+}
+
+// GetPRHistory returns the PR's audit trail, oldest event first.
+func (ps *PRService) GetPRHistory(ctx context.Context, prID string) (_ []models.PREvent, err error) {
+	ctx, span := startSpan(ctx, "PRService.GetPRHistory", attribute.String("pr.id", prID))
+	defer func() { endSpan(span, err) }()
+
+	if _, err := ps.GetPR(ctx, prID); err != nil {
+		return nil, err
+	}
+
+	return ps.storage.GetPREvents(ctx, prID)
+}
+
+func (ps *PRService) GetPRsByReviewer(ctx context.Context, userID string) (_ []models.PullRequestShort, err error) {
+	ctx, span := startSpan(ctx, "PRService.GetPRsByReviewer", attribute.String("user.id", userID))
+	defer func() { endSpan(span, err) }()
+
 	if userID == "" {
-		return nil, errors.New("user_id cannot be empty")
+		return nil, invalid("user_id cannot be empty")
 	}
 
 	if _, err := ps.userService.GetUser(ctx, userID); err != nil {
@@ -229,5 +760,5 @@ func (ps *PRService) IsPRMerged(ctx context.Context, prID string) (bool, error)
 		return false, err
 	}
 
-	return pr.Status == "MERGED", nil
+	return pr.Status == models.PRStatusMerged, nil
 }
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+)
+
+const (
+	StrategyRandom      = "random"
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastLoaded = "least_loaded"
+	StrategyWeighted    = "weighted"
+
+	DefaultReviewerStrategy = StrategyRandom
+)
+
+// ReviewerSelector picks up to count reviewers out of candidates. Candidates
+// are already filtered down to active members of teamName that may review the PR.
+type ReviewerSelector interface {
+	Select(ctx context.Context, teamName string, candidates []string, count int) ([]string, error)
+}
+
+func NewReviewerSelectors(s storage.Store) map[string]ReviewerSelector {
+	return map[string]ReviewerSelector{
+		StrategyRandom:      randomSelector{},
+		StrategyRoundRobin:  roundRobinSelector{storage: s},
+		StrategyLeastLoaded: leastLoadedSelector{storage: s},
+		StrategyWeighted:    weightedSelector{storage: s},
+	}
+}
+
+func IsValidReviewerStrategy(strategy string) bool {
+	switch strategy {
+	case StrategyRandom, StrategyRoundRobin, StrategyLeastLoaded, StrategyWeighted:
+		return true
+	}
+
+	return false
+}
+
+type randomSelector struct{}
+
+func (randomSelector) Select(_ context.Context, _ string, candidates []string, count int) ([]string, error) {
+	shuffled := shuffle(candidates)
+
+	return shuffled[:min(count, len(shuffled))], nil
+}
+
+// roundRobinSelector prefers whoever was assigned a review least recently,
+// so reviews rotate through the team regardless of which replica serves them.
+type roundRobinSelector struct {
+	storage storage.Store
+}
+
+func (s roundRobinSelector) Select(ctx context.Context, _ string, candidates []string, count int) ([]string, error) {
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	lastAssigned, err := s.storage.GetLastAssignedAt(ctx, candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := shuffle(candidates)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ti, okI := lastAssigned[ordered[i]]
+		tj, okJ := lastAssigned[ordered[j]]
+
+		if okI != okJ {
+			return !okI
+		}
+
+		return ti.Before(tj)
+	})
+
+	return ordered[:min(count, len(ordered))], nil
+}
+
+// leastLoadedSelector ranks candidates by the number of OPEN PRs they are
+// currently reviewing. Candidates with equal load are picked at random.
+type leastLoadedSelector struct {
+	storage storage.Store
+}
+
+func (s leastLoadedSelector) Select(ctx context.Context, teamName string, candidates []string, count int) ([]string, error) {
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	load, err := s.storage.GetOpenReviewLoad(ctx, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := shuffle(candidates)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return load[ordered[i]] < load[ordered[j]]
+	})
+
+	return ordered[:min(count, len(ordered))], nil
+}
+
+// weightedSelector samples without replacement, each candidate being picked
+// with probability proportional to its review_weight. Zero-weight members are
+// only used once nobody with a positive weight is left.
+type weightedSelector struct {
+	storage storage.Store
+}
+
+func (s weightedSelector) Select(ctx context.Context, _ string, candidates []string, count int) ([]string, error) {
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	weights, err := s.storage.GetReviewWeights(ctx, candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pool := shuffle(candidates)
+	selected := make([]string, 0, min(count, len(pool)))
+
+	for len(selected) < count && len(pool) > 0 {
+		total := 0
+		for _, id := range pool {
+			total += weights[id]
+		}
+
+		idx := 0
+		if total > 0 {
+			r := rand.Intn(total)
+			for i, id := range pool {
+				r -= weights[id]
+				if r < 0 {
+					idx = i
+					break
+				}
+			}
+		}
+
+		selected = append(selected, pool[idx])
+		pool = append(pool[:idx], pool[idx+1:]...)
+	}
+
+	return selected, nil
+}
+
+func (ps *PRService) selectorFor(settings *models.TeamSettings) (ReviewerSelector, error) {
+	selector, ok := ps.selectors[settings.ReviewerStrategy]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown reviewer strategy %q for team %s", settings.ReviewerStrategy, settings.TeamName)
+	}
+
+	return selector, nil
+}
+
+func shuffle(ids []string) []string {
+	shuffled := make([]string, len(ids))
+	copy(shuffled, ids)
+
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	return shuffled
+}
@@ -7,13 +7,25 @@ import (
 )
 
 type StatsService struct {
-	storage *storage.PostgresStorage
+	storage storage.Store
 }
 
-func NewStatsService(s *storage.PostgresStorage) *StatsService {
+func NewStatsService(s storage.Store) *StatsService {
 	return &StatsService{storage: s}
 }
 
-func (a *StatsService) GetReviewAssignmentsCount(ctx context.Context) (map[string]int, error) {
+// GetReviewAssignmentsCount counts review assignments on OPEN and MERGED PRs.
+// Drafts never have reviewers and CLOSED PRs are treated as abandoned.
+func (a *StatsService) GetReviewAssignmentsCount(ctx context.Context) (_ map[string]int, err error) {
+	ctx, span := startSpan(ctx, "StatsService.GetReviewAssignmentsCount")
+	defer func() { endSpan(span, err) }()
+
 	return a.storage.GetReviewAssignmentsCount(ctx)
 }
+
+func (a *StatsService) GetPRCountByStatus(ctx context.Context) (_ map[string]int, err error) {
+	ctx, span := startSpan(ctx, "StatsService.GetPRCountByStatus")
+	defer func() { endSpan(span, err) }()
+
+	return a.storage.GetPRCountByStatus(ctx)
+}
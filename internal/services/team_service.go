@@ -3,71 +3,177 @@ package services
 import (
 	"context"
 	"errors"
-	"fmt"
+	"log/slog"
+	"slices"
 
 	"github.com/Jersonmade/pr-reviewer-service/internal/models"
 	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type TeamService struct {
-	storage *storage.PostgresStorage
+	storage storage.Store
+	options Options
+	logger  *slog.Logger
 }
 
-func NewTeamService(s *storage.PostgresStorage) *TeamService {
+func NewTeamService(s storage.Store, options Options, logger *slog.Logger) *TeamService {
 	return &TeamService{
 		storage: s,
+		options: options,
+		logger:  logger,
 	}
 }
 
-func (ts *TeamService) CreateTeam(ctx context.Context, team *models.Team) (*models.Team, error) {
+func (ts *TeamService) CreateTeam(ctx context.Context, team *models.Team) (_ *models.Team, err error) {
+	ctx, span := startSpan(ctx, "TeamService.CreateTeam", attribute.String("team.name", team.TeamName))
+	defer func() { endSpan(span, err) }()
+
 	if team.TeamName == "" {
-		return nil, errors.New("team_name cannot be empty")
+		return nil, invalid("team_name cannot be empty")
 	}
 
 	if len(team.Members) == 0 {
-		return nil, errors.New("team must have at least one member")
+		return nil, invalid("team must have at least one member")
+	}
+
+	if team.ReviewerStrategy == "" {
+		team.ReviewerStrategy = ts.options.ReviewerStrategy
+	}
+
+	if team.MaxReviewers == 0 {
+		team.MaxReviewers = ts.options.MaxReviewers
+	}
+
+	err = ts.validateTeamSettings(ctx, &models.TeamSettings{
+		TeamName:                team.TeamName,
+		ReviewerStrategy:        team.ReviewerStrategy,
+		MinReviewers:            team.MinReviewers,
+		MaxReviewers:            team.MaxReviewers,
+		RequiredApprovals:       team.RequiredApprovals,
+		BlockOnChangesRequested: team.BlockOnChangesRequested,
+		RequireAllReviewers:     team.RequireAllReviewers,
+		BackupTeams:             team.BackupTeams,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ts.prepareNewMembers(ctx, team.Members); err != nil {
+		return nil, err
+	}
+
+	err = ts.storage.CreateTeam(ctx, team)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrTeamExists) {
+			return nil, ErrTeamExists
+		}
+
+		if errors.Is(err, storage.ErrMemberExists) {
+			return nil, ErrMemberExists
+		}
+
+		return nil, err
 	}
 
-	for i, member := range team.Members {
+	ts.logger.InfoContext(ctx, "team created", "members", len(team.Members))
+
+	return ts.storage.GetTeam(ctx, team.TeamName)
+}
+
+// AddTeamMembers adds new users, or users previously removed from their
+// team, to an existing team. Users who belong to another team have to be
+// moved with TransferUser instead.
+func (ts *TeamService) AddTeamMembers(ctx context.Context, teamName string, members []models.TeamMember) (_ *models.Team, err error) {
+	ctx, span := startSpan(ctx, "TeamService.AddTeamMembers", attribute.String("team.name", teamName))
+	defer func() { endSpan(span, err) }()
+
+	if teamName == "" {
+		return nil, invalid("team_name cannot be empty")
+	}
+
+	if err := authorizeTeam(ctx, teamName); err != nil {
+		return nil, err
+	}
+
+	if len(members) == 0 {
+		return nil, invalid("members cannot be empty")
+	}
+
+	if err := ts.prepareNewMembers(ctx, members); err != nil {
+		return nil, err
+	}
+
+	if err := ts.storage.AddTeamMembers(ctx, teamName, members); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			return nil, ErrTeamNotFound
+		case errors.Is(err, storage.ErrMemberExists):
+			return nil, ErrMemberExists
+		}
+
+		return nil, err
+	}
+
+	return ts.storage.GetTeam(ctx, teamName)
+}
+
+// prepareNewMembers validates members about to join a team and makes sure
+// none of them already belongs to a team.
+func (ts *TeamService) prepareNewMembers(ctx context.Context, members []models.TeamMember) error {
+	ids := make([]string, 0, len(members))
+	seen := make(map[string]bool, len(members))
+
+	for i, member := range members {
 		if member.UserID == "" {
-			return nil, fmt.Errorf("member at index %d has empty user_id", i)
+			return invalid("member at index %d has empty user_id", i)
 		}
 
 		if member.Username == "" {
-			return nil, fmt.Errorf("member at index %d has empty username", i)
+			return invalid("member at index %d has empty username", i)
+		}
+
+		if member.ReviewWeight < 0 {
+			return invalid("member at index %d has negative review_weight", i)
 		}
-	}
 
-	seen := make(map[string]bool)
-	for _, member := range team.Members {
 		if seen[member.UserID] {
-			return nil, fmt.Errorf("duplicate user_id: %s", member.UserID)
+			return invalid("duplicate user_id: %s", member.UserID)
 		}
 		seen[member.UserID] = true
+		ids = append(ids, member.UserID)
 	}
 
-	err := ts.storage.CreateTeam(ctx, team)
+	existing, err := ts.storage.GetUsersByIDs(ctx, ids)
 
 	if err != nil {
-		if err.Error() == "TEAM_EXISTS" {
-			return nil, errors.New("TEAM_EXISTS")
+		return err
+	}
+
+	for _, user := range existing {
+		if user.TeamName != "" {
+			return ErrMemberExists.WithMessage("user %s already belongs to team %s", user.UserID, user.TeamName)
 		}
-		return nil, err
 	}
 
-	return ts.storage.GetTeam(ctx, team.TeamName)
+	return nil
 }
 
-func (ts *TeamService) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+func (ts *TeamService) GetTeam(ctx context.Context, teamName string) (_ *models.Team, err error) {
+	ctx, span := startSpan(ctx, "TeamService.GetTeam", attribute.String("team.name", teamName))
+	defer func() { endSpan(span, err) }()
+
 	if teamName == "" {
-		return nil, errors.New("team_name cannot be empty")
+		return nil, invalid("team_name cannot be empty")
 	}
 
 	team, err := ts.storage.GetTeam(ctx, teamName)
 
 	if err != nil {
-		if err.Error() == "NOT_FOUND" {
-			return nil, errors.New("NOT_FOUND")
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrTeamNotFound
 		}
 
 		return nil, err
@@ -76,6 +182,242 @@ func (ts *TeamService) GetTeam(ctx context.Context, teamName string) (*models.Te
 	return team, nil
 }
 
+// UpdateTeamSettings applies the non-nil fields of the update on top of the
+// team's current settings.
+func (ts *TeamService) UpdateTeamSettings(ctx context.Context, update *models.TeamSettingsUpdate) (_ *models.TeamSettings, err error) {
+	ctx, span := startSpan(ctx, "TeamService.UpdateTeamSettings", attribute.String("team.name", update.TeamName))
+	defer func() { endSpan(span, err) }()
+
+	if update.TeamName == "" {
+		return nil, invalid("team_name cannot be empty")
+	}
+
+	if err := authorizeTeam(ctx, update.TeamName); err != nil {
+		return nil, err
+	}
+
+	settings, err := ts.storage.GetTeamSettings(ctx, update.TeamName)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrTeamNotFound
+		}
+
+		return nil, err
+	}
+
+	if update.ReviewerStrategy != nil {
+		settings.ReviewerStrategy = *update.ReviewerStrategy
+	}
+
+	if update.MinReviewers != nil {
+		settings.MinReviewers = *update.MinReviewers
+	}
+
+	if update.MaxReviewers != nil {
+		settings.MaxReviewers = *update.MaxReviewers
+	}
+
+	if update.RequiredApprovals != nil {
+		settings.RequiredApprovals = *update.RequiredApprovals
+	}
+
+	if update.BlockOnChangesRequested != nil {
+		settings.BlockOnChangesRequested = *update.BlockOnChangesRequested
+	}
+
+	if update.RequireAllReviewers != nil {
+		settings.RequireAllReviewers = *update.RequireAllReviewers
+	}
+
+	if update.BackupTeams != nil {
+		settings.BackupTeams = *update.BackupTeams
+	}
+
+	if err := ts.validateTeamSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	if err := ts.storage.UpdateTeamSettings(ctx, settings); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrTeamNotFound
+		}
+
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// RenameTeam gives the team a new name. The team keeps its members, settings
+// and PRs; events logged before the rename keep the old name.
+func (ts *TeamService) RenameTeam(ctx context.Context, teamName, newName string) (_ *models.Team, err error) {
+	ctx, span := startSpan(ctx, "TeamService.RenameTeam", attribute.String("team.name", teamName))
+	defer func() { endSpan(span, err) }()
+
+	if teamName == "" {
+		return nil, invalid("team_name cannot be empty")
+	}
+
+	if newName == "" {
+		return nil, invalid("new_team_name cannot be empty")
+	}
+
+	if err := authorizeTeam(ctx, teamName); err != nil {
+		return nil, err
+	}
+
+	if newName == teamName {
+		return nil, invalid("new_team_name must differ from team_name")
+	}
+
+	if err := ts.storage.RenameTeam(ctx, teamName, newName); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			return nil, ErrTeamNotFound
+		case errors.Is(err, storage.ErrTeamExists):
+			return nil, ErrTeamExists
+		}
+
+		return nil, err
+	}
+
+	ts.logger.InfoContext(ctx, "team renamed", "new_team_name", newName)
+
+	return ts.storage.GetTeam(ctx, newName)
+}
+
+// DeleteTeam deletes the team. With a target team its members move there
+// together with their PRs and reviews. Without one they are left without a
+// team and deactivated, which is refused while any of them authors a DRAFT or
+// OPEN PR or reviews an OPEN one.
+func (ts *TeamService) DeleteTeam(ctx context.Context, teamName, targetTeam string) (_ *models.TeamDeletionResult, err error) {
+	ctx, span := startSpan(ctx, "TeamService.DeleteTeam", attribute.String("team.name", teamName))
+	defer func() { endSpan(span, err) }()
+
+	team, err := ts.GetTeam(ctx, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if targetTeam == teamName {
+		return nil, invalid("target_team must differ from team_name")
+	}
+
+	if targetTeam != "" {
+		if _, err := ts.storage.GetTeamSettings(ctx, targetTeam); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, ErrTeamNotFound.WithMessage("target team %s not found", targetTeam)
+			}
+
+			return nil, err
+		}
+	} else if err := ts.checkNoActivePRs(ctx, team); err != nil {
+		return nil, err
+	}
+
+	members, err := ts.storage.DeleteTeam(ctx, teamName, targetTeam)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			return nil, ErrTeamNotFound
+		case errors.Is(err, storage.ErrStatusConflict):
+			return nil, errMembershipChanged
+		}
+
+		return nil, err
+	}
+
+	ts.logger.InfoContext(ctx, "team deleted", "target_team", targetTeam, "members", members)
+
+	return &models.TeamDeletionResult{
+		TeamName:   teamName,
+		TargetTeam: targetTeam,
+		Members:    members,
+	}, nil
+}
+
+func (ts *TeamService) checkNoActivePRs(ctx context.Context, team *models.Team) error {
+	ids := make([]string, 0, len(team.Members))
+	for _, member := range team.Members {
+		ids = append(ids, member.UserID)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	prIDs, err := ts.storage.GetActivePRsByAuthors(ctx, ids)
+
+	if err != nil {
+		return err
+	}
+
+	reviewed, err := ts.storage.GetOpenPRsReviewedBy(ctx, ids)
+
+	if err != nil {
+		return err
+	}
+
+	for _, pr := range reviewed {
+		if !slices.Contains(prIDs, pr.PullRequestID) {
+			prIDs = append(prIDs, pr.PullRequestID)
+		}
+	}
+
+	if len(prIDs) > 0 {
+		return ErrTeamHasOpenPRs.WithDetails(map[string]interface{}{"pull_requests": prIDs})
+	}
+
+	return nil
+}
+
+func (ts *TeamService) validateTeamSettings(ctx context.Context, settings *models.TeamSettings) error {
+	if !IsValidReviewerStrategy(settings.ReviewerStrategy) {
+		return invalid("invalid team settings: unknown reviewer_strategy %s", settings.ReviewerStrategy)
+	}
+
+	if settings.MinReviewers < 0 {
+		return invalid("invalid team settings: min_reviewers cannot be negative")
+	}
+
+	if settings.MaxReviewers < 1 {
+		return invalid("invalid team settings: max_reviewers must be at least 1")
+	}
+
+	if settings.MinReviewers > settings.MaxReviewers {
+		return invalid("invalid team settings: min_reviewers cannot exceed max_reviewers")
+	}
+
+	if settings.RequiredApprovals < 0 || settings.RequiredApprovals > settings.MaxReviewers {
+		return invalid("invalid team settings: required_approvals must be between 0 and max_reviewers")
+	}
+
+	seen := make(map[string]bool, len(settings.BackupTeams))
+	for _, backup := range settings.BackupTeams {
+		if backup == settings.TeamName {
+			return invalid("invalid team settings: team cannot be its own backup")
+		}
+
+		if seen[backup] {
+			return invalid("invalid team settings: duplicate backup team %s", backup)
+		}
+		seen[backup] = true
+
+		if _, err := ts.storage.GetTeamSettings(ctx, backup); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return invalid("invalid team settings: backup team %s does not exist", backup)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (ts *TeamService) ValidateTeamExists(ctx context.Context, teamName string) error {
 	_, err := ts.GetTeam(ctx, teamName)
 	return err
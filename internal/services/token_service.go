@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tokenPrefix makes the tokens easy to recognise, e.g. by secret scanners.
+const tokenPrefix = "prs_"
+
+// TokenService issues and checks API tokens. Only a hash of each token is
+// stored, so a token that is lost has to be revoked and issued again.
+type TokenService struct {
+	storage storage.Store
+	logger  *slog.Logger
+}
+
+func NewTokenService(s storage.Store, logger *slog.Logger) *TokenService {
+	return &TokenService{storage: s, logger: logger}
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateToken issues a token with the given name, role and scope. The
+// returned token carries the secret, which is not retrievable afterwards.
+func (ts *TokenService) CreateToken(ctx context.Context, token *models.APIToken) (_ *models.APIToken, err error) {
+	ctx, span := startSpan(ctx, "TokenService.CreateToken", attribute.String("token.role", token.Role))
+	defer func() { endSpan(span, err) }()
+
+	if err := ts.validateToken(ctx, token); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 32)
+
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	if err := ts.storage.CreateToken(ctx, token, hashToken(secret)); err != nil {
+		return nil, err
+	}
+
+	token.Token = secret
+
+	ts.logger.InfoContext(ctx, "api token created", "token_id", token.TokenID, "token_name", token.Name, "role", token.Role)
+
+	return token, nil
+}
+
+// validateToken checks the role and that team leads are scoped to an
+// existing team and members to an existing user, and nothing else is.
+func (ts *TokenService) validateToken(ctx context.Context, token *models.APIToken) error {
+	if token.Name == "" {
+		return invalid("name cannot be empty")
+	}
+
+	if !auth.IsValidRole(token.Role) {
+		return invalid("unknown role %q", token.Role)
+	}
+
+	if (token.Role == auth.RoleTeamLead) != (token.TeamName != "") {
+		return invalid("team_name is required for, and only allowed with, role %s", auth.RoleTeamLead)
+	}
+
+	if (token.Role == auth.RoleMember) != (token.UserID != "") {
+		return invalid("user_id is required for, and only allowed with, role %s", auth.RoleMember)
+	}
+
+	if token.TeamName != "" {
+		if _, err := ts.storage.GetTeamSettings(ctx, token.TeamName); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return ErrTeamNotFound
+			}
+
+			return err
+		}
+	}
+
+	if token.UserID != "" {
+		if _, err := ts.storage.GetUser(ctx, token.UserID); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return ErrUserNotFound
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Authenticate returns the principal a bearer token acts as. Unknown and
+// revoked tokens are both reported as ErrUnauthorized.
+func (ts *TokenService) Authenticate(ctx context.Context, secret string) (*auth.Principal, error) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return nil, ErrUnauthorized
+	}
+
+	token, err := ts.storage.GetTokenByHash(ctx, hashToken(secret))
+
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrUnauthorized
+		}
+
+		return nil, err
+	}
+
+	if token.RevokedAt != nil {
+		return nil, ErrUnauthorized
+	}
+
+	return &auth.Principal{
+		TokenID:  token.TokenID,
+		Name:     token.Name,
+		Role:     token.Role,
+		TeamName: token.TeamName,
+		UserID:   token.UserID,
+	}, nil
+}
+
+func (ts *TokenService) ListTokens(ctx context.Context) (_ []models.APIToken, err error) {
+	ctx, span := startSpan(ctx, "TokenService.ListTokens")
+	defer func() { endSpan(span, err) }()
+
+	return ts.storage.ListTokens(ctx)
+}
+
+// RevokeToken stops the token from authenticating. It takes effect on the
+// next request.
+func (ts *TokenService) RevokeToken(ctx context.Context, tokenID int64) (_ *models.APIToken, err error) {
+	ctx, span := startSpan(ctx, "TokenService.RevokeToken", attribute.Int64("token.id", tokenID))
+	defer func() { endSpan(span, err) }()
+
+	token, err := ts.storage.RevokeToken(ctx, tokenID)
+
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrTokenNotFound
+		}
+
+		return nil, err
+	}
+
+	ts.logger.InfoContext(ctx, "api token revoked", "token_id", token.TokenID, "token_name", token.Name)
+
+	return token, nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Jersonmade/pr-reviewer-service/internal/services")
+
+// startSpan starts the span of a service method. Callers end it with
+// endSpan, deferred over the method's named error result.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records how the method finished. Domain errors are outcomes the
+// client is told about, so they are only tagged with their code; anything
+// else marks the span as failed.
+func endSpan(span trace.Span, err error) {
+	var domainErr *DomainError
+
+	switch {
+	case err == nil:
+	case errors.As(err, &domainErr):
+		span.SetAttributes(attribute.String("error.code", domainErr.Code))
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -6,26 +6,30 @@ import (
 
 	"github.com/Jersonmade/pr-reviewer-service/internal/models"
 	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type UserService struct {
-	storage *storage.PostgresStorage
+	storage storage.Store
 }
 
-func NewUserService(s *storage.PostgresStorage) *UserService {
+func NewUserService(s storage.Store) *UserService {
 	return &UserService{storage: s}
 }
 
-func (us *UserService) GetUser(ctx context.Context, userID string) (*models.User, error) {
+func (us *UserService) GetUser(ctx context.Context, userID string) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetUser", attribute.String("user.id", userID))
+	defer func() { endSpan(span, err) }()
+
 	if userID == "" {
-		return nil, errors.New("User ID cannot be empty")
+		return nil, invalid("user_id cannot be empty")
 	}
 
 	user, err := us.storage.GetUser(ctx, userID)
 
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
-			return nil, errors.New("USER_NOT_FOUND")
+			return nil, ErrUserNotFound
 		}
 
 		return nil, err
@@ -34,26 +38,40 @@ func (us *UserService) GetUser(ctx context.Context, userID string) (*models.User
 	return user, nil
 }
 
-func (us *UserService) SetUserActive(ctx context.Context, userID string, isActive bool) (*models.User, error) {
+// SetUserActive only activates users. Deactivating goes through
+// PRService.DeactivateUser, which hands the user's open reviews over in the
+// same transaction; flipping the flag alone would strand them.
+func (us *UserService) SetUserActive(ctx context.Context, userID string, isActive bool) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.SetUserActive", attribute.String("user.id", userID))
+	defer func() { endSpan(span, err) }()
+
 	if userID == "" {
-		return nil, errors.New("User ID cannot be empty")
+		return nil, invalid("user_id cannot be empty")
 	}
 
-	_, err := us.storage.GetUser(ctx, userID)
+	if !isActive {
+		return nil, invalid("deactivating a user must hand over their open reviews")
+	}
+
+	current, err := us.storage.GetUser(ctx, userID)
 
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
-			return nil, errors.New("USER_NOT_FOUND")
+			return nil, ErrUserNotFound
 		}
 
 		return nil, err
 	}
 
+	if err := authorizeTeam(ctx, current.TeamName); err != nil {
+		return nil, err
+	}
+
 	user, err := us.storage.UpdateUserActive(ctx, userID, isActive)
 
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
-			return nil, errors.New("USER_NOT_FOUND")
+			return nil, ErrUserNotFound
 		}
 
 		return nil, err
@@ -62,30 +80,33 @@ func (us *UserService) SetUserActive(ctx context.Context, userID string, isActiv
 	return user, nil
 }
 
-func (us *UserService) GetActiveTeamMembers(ctx context.Context, teamName, excludeUserID string, excludeReviewers []string) ([]string, error) {
+func (us *UserService) GetActiveTeamMembers(ctx context.Context, teamName, excludeUserID string, excludeReviewers []string) (_ []string, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetActiveTeamMembers", attribute.String("team.name", teamName))
+	defer func() { endSpan(span, err) }()
+
 	if teamName == "" {
-		return nil, errors.New("team_name cannot be empty")
+		return nil, invalid("team_name cannot be empty")
 	}
 
 	return us.storage.GetActiveTeamMembers(ctx, teamName, excludeUserID, excludeReviewers)
 }
 
 func (us *UserService) ValidateUser(ctx context.Context, userID string) error {
-	user, err := us.storage.GetUser(ctx, userID)
+	user, err := us.GetUser(ctx, userID)
 
 	if err != nil {
 		return err
 	}
 
 	if !user.IsActive {
-		return errors.New("User is not active")
+		return ErrUserInactive
 	}
 
 	return nil
 }
 
 func (us *UserService) GetUserTeamName(ctx context.Context, userID string) (string, error) {
-	user, err := us.storage.GetUser(ctx, userID)
+	user, err := us.GetUser(ctx, userID)
 
 	if err != nil {
 		return "", err
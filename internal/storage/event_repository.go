@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+)
+
+// insertEvent appends to the audit log inside the caller's transaction, so an
+// event exists if and only if the change it describes was committed. Unless
+// set, the actor is taken from ctx.
+func insertEvent(ctx context.Context, tx *sql.Tx, event *models.PREvent) error {
+	if event.Actor == "" {
+		event.Actor = auth.Actor(ctx)
+	}
+
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO pr_events (
+            pull_request_id, event_type, user_id, from_status, to_status, from_team, to_team, actor, reason
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `,
+		nullString(event.PullRequestID), event.EventType, nullString(event.UserID),
+		nullString(event.FromStatus), nullString(event.ToStatus),
+		nullString(event.FromTeam), nullString(event.ToTeam),
+		nullString(event.Actor), nullString(event.Reason),
+	)
+
+	return err
+}
+
+// insertEvents appends the events in order with a single statement.
+func insertEvents(ctx context.Context, tx *sql.Tx, events []models.PREvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	columns := make([][]string, 9)
+	for i := range events {
+		event := &events[i]
+		if event.Actor == "" {
+			event.Actor = auth.Actor(ctx)
+		}
+
+		for c, value := range []string{
+			event.PullRequestID, event.EventType, event.UserID,
+			event.FromStatus, event.ToStatus, event.FromTeam, event.ToTeam,
+			event.Actor, event.Reason,
+		} {
+			columns[c] = append(columns[c], value)
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO pr_events (
+            pull_request_id, event_type, user_id, from_status, to_status, from_team, to_team, actor, reason
+        )
+        SELECT NULLIF(pull_request_id, ''), event_type, NULLIF(user_id, ''),
+               NULLIF(from_status, ''), NULLIF(to_status, ''),
+               NULLIF(from_team, ''), NULLIF(to_team, ''),
+               NULLIF(actor, ''), NULLIF(reason, '')
+        FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[], $7::text[], $8::text[], $9::text[])
+            WITH ORDINALITY AS e(pull_request_id, event_type, user_id, from_status, to_status, from_team, to_team, actor, reason, n)
+        ORDER BY n
+    `,
+		columns[0], columns[1], columns[2], columns[3], columns[4],
+		columns[5], columns[6], columns[7], columns[8],
+	)
+
+	return err
+}
+
+func (s *PostgresStorage) GetPREvents(ctx context.Context, prID string) ([]models.PREvent, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT event_id, pull_request_id, event_type, user_id, from_status, to_status,
+               from_team, to_team, actor, reason, created_at
+        FROM pr_events
+        WHERE pull_request_id = $1
+        ORDER BY event_id
+    `, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	events := []models.PREvent{}
+	for rows.Next() {
+		var event models.PREvent
+		var prIDCol, userID, fromStatus, toStatus, fromTeam, toTeam, actor, reason sql.NullString
+		var createdAt time.Time
+
+		err := rows.Scan(&event.EventID, &prIDCol, &event.EventType, &userID,
+			&fromStatus, &toStatus, &fromTeam, &toTeam, &actor, &reason, &createdAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		event.PullRequestID = prIDCol.String
+		event.UserID = userID.String
+		event.FromStatus = fromStatus.String
+		event.ToStatus = toStatus.String
+		event.FromTeam = fromTeam.String
+		event.ToTeam = toTeam.String
+		event.Actor = actor.String
+		event.Reason = reason.String
+		event.CreatedAt = &createdAt
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+)
+
+// addMembers inserts new users into the team or takes back users that were
+// removed from their previous team. A user who still belongs to a team is
+// never moved implicitly: ErrMemberExists is returned instead.
+func addMembers(ctx context.Context, tx *sql.Tx, teamName string, members []models.TeamMember) error {
+	for _, member := range members {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO users (user_id, username, team_name, is_active, review_weight)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id) DO UPDATE SET
+				username = EXCLUDED.username,
+				team_name = EXCLUDED.team_name,
+				is_active = EXCLUDED.is_active,
+				review_weight = EXCLUDED.review_weight,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE users.team_name IS NULL
+			`, member.UserID, member.Username, teamName, member.IsActive, member.ReviewWeight)
+
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrMemberExists
+		}
+
+		err = insertEvent(ctx, tx, &models.PREvent{
+			EventType: models.EventMemberAdded,
+			UserID:    member.UserID,
+			ToTeam:    teamName,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *PostgresStorage) AddTeamMembers(ctx context.Context, teamName string, members []models.TeamMember) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	var exists bool
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)",
+		teamName,
+	).Scan(&exists)
+
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrNotFound
+	}
+
+	if err := addMembers(ctx, tx, teamName, members); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveTeamMembers detaches the users from the team and deactivates them,
+// applying the review changes in the same transaction. It returns
+// ErrStatusConflict if any of the users left the team in the meantime.
+func (s *PostgresStorage) RemoveTeamMembers(ctx context.Context, teamName string, userIDs []string, changes []models.ReviewReassignment) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET team_name = NULL, is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ANY($1) AND team_name = $2
+	`, userIDs, teamName)
+
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected != int64(len(userIDs)) {
+		return ErrStatusConflict
+	}
+
+	events := make([]models.PREvent, len(userIDs))
+	for i, userID := range userIDs {
+		events[i] = models.PREvent{EventType: models.EventMemberRemoved, UserID: userID, FromTeam: teamName}
+	}
+
+	if err := insertEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	if err := replaceReviewers(ctx, tx, changes, "reviewer removed from team"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TransferUser moves the user from fromTeam to toTeam and applies the review
+// changes in the same transaction. It returns ErrStatusConflict if the user
+// is no longer in fromTeam.
+func (s *PostgresStorage) TransferUser(ctx context.Context, userID, fromTeam, toTeam string, changes []models.ReviewReassignment) (*models.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET team_name = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND team_name = $2
+	`, userID, fromTeam, toTeam)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected == 0 {
+		return nil, ErrStatusConflict
+	}
+
+	err = insertEvent(ctx, tx, &models.PREvent{
+		EventType: models.EventMemberTransferred,
+		UserID:    userID,
+		FromTeam:  fromTeam,
+		ToTeam:    toTeam,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := replaceReviewers(ctx, tx, changes, "reviewer moved to team "+toTeam); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, userID)
+}
@@ -0,0 +1,1200 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+)
+
+type memoryUser struct {
+	user   models.User
+	weight int
+}
+
+type memoryReviewer struct {
+	reviewerID   string
+	fallbackTeam string
+	assignedBy   string
+	assignedAt   time.Time
+}
+
+// memoryPR keeps reviewers and reviews next to the PR row, the way they are
+// joined in pr_reviewers and pr_reviews.
+type memoryPR struct {
+	pr        models.PullRequest
+	reviewers []memoryReviewer
+	reviews   []models.Review
+}
+
+type memoryToken struct {
+	token models.APIToken
+	hash  string
+}
+
+// MemoryStorage is an in-process Store with the same semantics and sentinel
+// errors as PostgresStorage. Every method holds a single lock, so multi-step
+// operations are atomic; operations that can fail halfway stage their changes
+// and only apply them once every step succeeded. Like the services expect
+// from Postgres, referential checks (author exists, backup team exists) are
+// done by the caller before writing.
+type MemoryStorage struct {
+	mu          sync.RWMutex
+	teams       map[string]*models.TeamSettings
+	users       map[string]*memoryUser
+	prs         map[string]*memoryPR
+	events      []models.PREvent
+	tokens      []*memoryToken
+	lastTokenID int64
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		teams: make(map[string]*models.TeamSettings),
+		users: make(map[string]*memoryUser),
+		prs:   make(map[string]*memoryPR),
+	}
+}
+
+func (m *MemoryStorage) Close() error {
+	return nil
+}
+
+func (m *MemoryStorage) CreateTeam(ctx context.Context, team *models.Team) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.teams[team.TeamName]; exists {
+		return ErrTeamExists
+	}
+
+	if err := m.checkNewMembers(team.Members); err != nil {
+		return err
+	}
+
+	m.teams[team.TeamName] = &models.TeamSettings{
+		TeamName:                team.TeamName,
+		ReviewerStrategy:        team.ReviewerStrategy,
+		MinReviewers:            team.MinReviewers,
+		MaxReviewers:            team.MaxReviewers,
+		RequiredApprovals:       team.RequiredApprovals,
+		BlockOnChangesRequested: team.BlockOnChangesRequested,
+		RequireAllReviewers:     team.RequireAllReviewers,
+		BackupTeams:             append([]string{}, team.BackupTeams...),
+		CreatedBy:               auth.Actor(ctx),
+	}
+
+	m.addMembers(ctx, team.TeamName, team.Members)
+
+	return nil
+}
+
+// checkNewMembers mirrors the guard in the Postgres addMembers: only unknown
+// users and users without a team may be added.
+func (m *MemoryStorage) checkNewMembers(members []models.TeamMember) error {
+	for _, member := range members {
+		if u, ok := m.users[member.UserID]; ok && u.user.TeamName != "" {
+			return ErrMemberExists
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) addMembers(ctx context.Context, teamName string, members []models.TeamMember) {
+	for _, member := range members {
+		m.users[member.UserID] = &memoryUser{
+			user: models.User{
+				UserID:   member.UserID,
+				Username: member.Username,
+				TeamName: teamName,
+				IsActive: member.IsActive,
+			},
+			weight: member.ReviewWeight,
+		}
+
+		m.appendEvent(ctx, models.PREvent{
+			EventType: models.EventMemberAdded,
+			UserID:    member.UserID,
+			ToTeam:    teamName,
+		})
+	}
+}
+
+func (m *MemoryStorage) AddTeamMembers(ctx context.Context, teamName string, members []models.TeamMember) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.teams[teamName]; !ok {
+		return ErrNotFound
+	}
+
+	if err := m.checkNewMembers(members); err != nil {
+		return err
+	}
+
+	m.addMembers(ctx, teamName, members)
+
+	return nil
+}
+
+func (m *MemoryStorage) RemoveTeamMembers(ctx context.Context, teamName string, userIDs []string, changes []models.ReviewReassignment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, userID := range userIDs {
+		if u, ok := m.users[userID]; !ok || u.user.TeamName != teamName {
+			return ErrStatusConflict
+		}
+	}
+
+	staged, events, err := m.stageReplacements(ctx, changes, "reviewer removed from team")
+
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		u := m.users[userID]
+		u.user.TeamName = ""
+		u.user.IsActive = false
+
+		m.appendEvent(ctx, models.PREvent{
+			EventType: models.EventMemberRemoved,
+			UserID:    userID,
+			FromTeam:  teamName,
+		})
+	}
+
+	m.applyReplacements(ctx, staged, events)
+
+	return nil
+}
+
+func (m *MemoryStorage) TransferUser(ctx context.Context, userID, fromTeam, toTeam string, changes []models.ReviewReassignment) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+
+	if !ok || u.user.TeamName != fromTeam {
+		return nil, ErrStatusConflict
+	}
+
+	staged, events, err := m.stageReplacements(ctx, changes, "reviewer moved to team "+toTeam)
+
+	if err != nil {
+		return nil, err
+	}
+
+	u.user.TeamName = toTeam
+
+	m.appendEvent(ctx, models.PREvent{
+		EventType: models.EventMemberTransferred,
+		UserID:    userID,
+		FromTeam:  fromTeam,
+		ToTeam:    toTeam,
+	})
+	m.applyReplacements(ctx, staged, events)
+
+	user := u.user
+
+	return &user, nil
+}
+
+func (m *MemoryStorage) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	settings, ok := m.teams[teamName]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	members := []models.TeamMember{}
+	for _, u := range m.users {
+		if u.user.TeamName != teamName {
+			continue
+		}
+
+		members = append(members, models.TeamMember{
+			UserID:       u.user.UserID,
+			Username:     u.user.Username,
+			IsActive:     u.user.IsActive,
+			ReviewWeight: u.weight,
+		})
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Username < members[j].Username
+	})
+
+	return &models.Team{
+		TeamName:                teamName,
+		ReviewerStrategy:        settings.ReviewerStrategy,
+		MinReviewers:            settings.MinReviewers,
+		MaxReviewers:            settings.MaxReviewers,
+		RequiredApprovals:       settings.RequiredApprovals,
+		BlockOnChangesRequested: settings.BlockOnChangesRequested,
+		RequireAllReviewers:     settings.RequireAllReviewers,
+		BackupTeams:             append([]string{}, settings.BackupTeams...),
+		Members:                 members,
+		CreatedBy:               settings.CreatedBy,
+	}, nil
+}
+
+func (m *MemoryStorage) GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	settings, ok := m.teams[teamName]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	result := *settings
+	result.BackupTeams = append([]string{}, settings.BackupTeams...)
+
+	return &result, nil
+}
+
+func (m *MemoryStorage) GetBackupTeams(ctx context.Context, teamName string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	backups := []string{}
+	if settings, ok := m.teams[teamName]; ok {
+		backups = append(backups, settings.BackupTeams...)
+	}
+
+	return backups, nil
+}
+
+func (m *MemoryStorage) UpdateTeamSettings(ctx context.Context, settings *models.TeamSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.teams[settings.TeamName]
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	updated := *settings
+	updated.CreatedBy = current.CreatedBy
+	updated.BackupTeams = append([]string{}, settings.BackupTeams...)
+	m.teams[settings.TeamName] = &updated
+
+	return nil
+}
+
+func (m *MemoryStorage) RenameTeam(ctx context.Context, teamName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	settings, ok := m.teams[teamName]
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	if _, exists := m.teams[newName]; exists {
+		return ErrTeamExists
+	}
+
+	delete(m.teams, teamName)
+	settings.TeamName = newName
+	m.teams[newName] = settings
+
+	for _, other := range m.teams {
+		for i, backup := range other.BackupTeams {
+			if backup == teamName {
+				other.BackupTeams[i] = newName
+			}
+		}
+	}
+
+	for _, u := range m.users {
+		if u.user.TeamName == teamName {
+			u.user.TeamName = newName
+		}
+	}
+
+	for _, p := range m.prs {
+		for i := range p.reviewers {
+			if p.reviewers[i].fallbackTeam == teamName {
+				p.reviewers[i].fallbackTeam = newName
+			}
+		}
+	}
+
+	for _, t := range m.tokens {
+		if t.token.TeamName == teamName {
+			t.token.TeamName = newName
+		}
+	}
+
+	m.appendEvent(ctx, models.PREvent{
+		EventType: models.EventTeamRenamed,
+		FromTeam:  teamName,
+		ToTeam:    newName,
+	})
+
+	return nil
+}
+
+func (m *MemoryStorage) DeleteTeam(ctx context.Context, teamName, targetTeam string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.teams[teamName]; !ok {
+		return nil, ErrNotFound
+	}
+
+	if _, ok := m.teams[targetTeam]; targetTeam != "" && !ok {
+		return nil, ErrNotFound
+	}
+
+	members := []string{}
+	for _, u := range m.users {
+		if u.user.TeamName == teamName {
+			members = append(members, u.user.UserID)
+		}
+	}
+	sort.Strings(members)
+
+	if targetTeam == "" {
+		for _, p := range m.prs {
+			active := p.pr.Status == models.PRStatusDraft || p.pr.Status == models.PRStatusOpen
+			authored := active && slices.Contains(members, p.pr.AuthorID)
+			reviewed := p.pr.Status == models.PRStatusOpen && p.reviewedByAny(members)
+
+			if authored || reviewed {
+				return nil, ErrStatusConflict
+			}
+		}
+	}
+
+	for _, userID := range members {
+		u := m.users[userID]
+		event := models.PREvent{
+			EventType: models.EventMemberRemoved,
+			UserID:    userID,
+			FromTeam:  teamName,
+		}
+
+		if targetTeam == "" {
+			u.user.TeamName = ""
+			u.user.IsActive = false
+		} else {
+			u.user.TeamName = targetTeam
+			event.EventType = models.EventMemberTransferred
+			event.ToTeam = targetTeam
+		}
+
+		m.appendEvent(ctx, event)
+	}
+
+	delete(m.teams, teamName)
+
+	for _, other := range m.teams {
+		other.BackupTeams = slices.DeleteFunc(other.BackupTeams, func(backup string) bool {
+			return backup == teamName
+		})
+	}
+
+	m.tokens = slices.DeleteFunc(m.tokens, func(t *memoryToken) bool {
+		return t.token.TeamName == teamName
+	})
+
+	m.appendEvent(ctx, models.PREvent{
+		EventType: models.EventTeamDeleted,
+		FromTeam:  teamName,
+		ToTeam:    targetTeam,
+	})
+
+	return members, nil
+}
+
+func (m *MemoryStorage) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[userID]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	user := u.user
+
+	return &user, nil
+}
+
+func (m *MemoryStorage) GetUsersByIDs(ctx context.Context, userIDs []string) ([]models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := []models.User{}
+	for _, u := range m.users {
+		if slices.Contains(userIDs, u.user.UserID) {
+			users = append(users, u.user)
+		}
+	}
+
+	return users, nil
+}
+
+func (m *MemoryStorage) UpdateUserActive(ctx context.Context, userID string, isActive bool) (*models.User, error) {
+	return m.UpdateUserActiveWithReassignments(ctx, userID, isActive, nil)
+}
+
+func (m *MemoryStorage) UpdateUserActiveWithReassignments(ctx context.Context, userID string, isActive bool, changes []models.ReviewReassignment) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	staged, events, err := m.stageReplacements(ctx, changes, "reviewer deactivated")
+
+	if err != nil {
+		return nil, err
+	}
+
+	eventType := models.EventUserDeactivated
+	if isActive {
+		eventType = models.EventUserActivated
+	}
+
+	u.user.IsActive = isActive
+	m.appendEvent(ctx, models.PREvent{EventType: eventType, UserID: userID})
+	m.applyReplacements(ctx, staged, events)
+
+	user := u.user
+
+	return &user, nil
+}
+
+func (m *MemoryStorage) DeactivateUsersWithReassignments(ctx context.Context, userIDs []string, changes []models.ReviewReassignment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	staged, events, err := m.stageReplacements(ctx, changes, "reviewer deactivated")
+
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if u, ok := m.users[userID]; ok {
+			u.user.IsActive = false
+		}
+
+		m.appendEvent(ctx, models.PREvent{EventType: models.EventUserDeactivated, UserID: userID})
+	}
+
+	m.applyReplacements(ctx, staged, events)
+
+	return nil
+}
+
+func (m *MemoryStorage) GetActiveTeamMembers(ctx context.Context, teamName, excludeUserID string, excludeReviewers []string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	candidates := []string{}
+	for id, u := range m.users {
+		if teamName == "" || u.user.TeamName != teamName || !u.user.IsActive || id == excludeUserID {
+			continue
+		}
+
+		if slices.Contains(excludeReviewers, id) {
+			continue
+		}
+
+		candidates = append(candidates, id)
+	}
+
+	sort.Strings(candidates)
+
+	return candidates, nil
+}
+
+func (m *MemoryStorage) GetReviewWeights(ctx context.Context, userIDs []string) (map[string]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	weights := make(map[string]int, len(userIDs))
+	for _, id := range userIDs {
+		if u, ok := m.users[id]; ok {
+			weights[id] = u.weight
+		}
+	}
+
+	return weights, nil
+}
+
+func (m *MemoryStorage) GetLastAssignedAt(ctx context.Context, userIDs []string) (map[string]time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	lastAssigned := make(map[string]time.Time, len(userIDs))
+	for _, p := range m.prs {
+		for _, r := range p.reviewers {
+			if !slices.Contains(userIDs, r.reviewerID) {
+				continue
+			}
+
+			if last, ok := lastAssigned[r.reviewerID]; !ok || r.assignedAt.After(last) {
+				lastAssigned[r.reviewerID] = r.assignedAt
+			}
+		}
+	}
+
+	return lastAssigned, nil
+}
+
+func (m *MemoryStorage) CreatePR(ctx context.Context, pr *models.PullRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.prs[pr.PullRequestID]; exists {
+		return ErrPRExists
+	}
+
+	now := time.Now()
+	p := &memoryPR{
+		pr: models.PullRequest{
+			PullRequestID:   pr.PullRequestID,
+			PullRequestName: pr.PullRequestName,
+			AuthorID:        pr.AuthorID,
+			Status:          pr.Status,
+			CreatedAt:       &now,
+			CreatedBy:       auth.Actor(ctx),
+		},
+	}
+	m.prs[pr.PullRequestID] = p
+
+	m.appendEvent(ctx, models.PREvent{
+		PullRequestID: pr.PullRequestID,
+		EventType:     models.EventPRCreated,
+		UserID:        pr.AuthorID,
+		ToStatus:      pr.Status,
+	})
+
+	m.assignReviewers(ctx, p, pr.AssignedReviewers, pr.FallbackReviewers)
+
+	return nil
+}
+
+// assignReviewers mirrors the Postgres helper of the same name.
+func (m *MemoryStorage) assignReviewers(ctx context.Context, p *memoryPR, reviewers []string, fallback map[string]string) {
+	for _, reviewerID := range reviewers {
+		p.reviewers = append(p.reviewers, memoryReviewer{
+			reviewerID:   reviewerID,
+			fallbackTeam: fallback[reviewerID],
+			assignedBy:   auth.Actor(ctx),
+			assignedAt:   time.Now(),
+		})
+
+		reason := "auto-assigned"
+		if team := fallback[reviewerID]; team != "" {
+			reason = "auto-assigned from backup team " + team
+		}
+
+		m.appendEvent(ctx, models.PREvent{
+			PullRequestID: p.pr.PullRequestID,
+			EventType:     models.EventReviewerAssigned,
+			UserID:        reviewerID,
+			Reason:        reason,
+		})
+	}
+}
+
+// changeStatus mirrors the Postgres helper of the same name.
+func (m *MemoryStorage) changeStatus(ctx context.Context, prID, toStatus, reason string, from ...string) (*memoryPR, error) {
+	p, ok := m.prs[prID]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	current := p.pr.Status
+
+	if !slices.Contains(from, current) {
+		return nil, ErrStatusConflict
+	}
+
+	now := time.Now()
+	p.pr.Status = toStatus
+
+	if toStatus == models.PRStatusMerged {
+		p.pr.MergedAt = &now
+	}
+
+	p.pr.ClosedAt = nil
+	if toStatus == models.PRStatusClosed {
+		p.pr.ClosedAt = &now
+	}
+
+	m.appendEvent(ctx, models.PREvent{
+		PullRequestID: prID,
+		EventType:     models.EventStatusChanged,
+		FromStatus:    current,
+		ToStatus:      toStatus,
+		Reason:        reason,
+	})
+
+	return p, nil
+}
+
+func (m *MemoryStorage) GetPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.getPR(prID)
+}
+
+func (m *MemoryStorage) getPR(prID string) (*models.PullRequest, error) {
+	p, ok := m.prs[prID]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	pr := p.pr
+	pr.CreatedAt = copyTime(p.pr.CreatedAt)
+	pr.MergedAt = copyTime(p.pr.MergedAt)
+	pr.ClosedAt = copyTime(p.pr.ClosedAt)
+
+	pr.AssignedReviewers = []string{}
+	for _, r := range p.reviewers {
+		pr.AssignedReviewers = append(pr.AssignedReviewers, r.reviewerID)
+
+		if r.fallbackTeam != "" {
+			if pr.FallbackReviewers == nil {
+				pr.FallbackReviewers = make(map[string]string)
+			}
+			pr.FallbackReviewers[r.reviewerID] = r.fallbackTeam
+		}
+
+		if r.assignedBy != "" {
+			if pr.AssignedBy == nil {
+				pr.AssignedBy = make(map[string]string)
+			}
+			pr.AssignedBy[r.reviewerID] = r.assignedBy
+		}
+	}
+
+	pr.Reviews = copyReviews(p.reviews)
+
+	return &pr, nil
+}
+
+func (m *MemoryStorage) MergePR(ctx context.Context, prID string, check MergeCheck) (*models.PullRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pr, err := m.getPR(prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if pr.Status != models.PRStatusOpen {
+		return nil, ErrStatusConflict
+	}
+
+	forced, reason, err := check(pr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := m.changeStatus(ctx, prID, models.PRStatusMerged, reason, models.PRStatusOpen)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.pr.ForceMerged = forced
+	p.pr.ForceMergeReason = reason
+	p.pr.MergedBy = auth.Actor(ctx)
+
+	return m.getPR(prID)
+}
+
+func (m *MemoryStorage) ClosePR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.changeStatus(ctx, prID, models.PRStatusClosed, "", models.PRStatusOpen, models.PRStatusDraft)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.getPR(prID)
+}
+
+func (m *MemoryStorage) OpenPR(ctx context.Context, prID, fromStatus string, reviewers []string, fallback map[string]string) (*models.PullRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, err := m.changeStatus(ctx, prID, models.PRStatusOpen, "", fromStatus)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.assignReviewers(ctx, p, reviewers, fallback)
+
+	return m.getPR(prID)
+}
+
+func (m *MemoryStorage) ReassignReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID, fallbackTeam, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changes := []models.ReviewReassignment{{
+		PullRequestID: prID,
+		OldReviewerID: oldReviewerID,
+		NewReviewerID: newReviewerID,
+		FallbackTeam:  fallbackTeam,
+	}}
+
+	staged, events, err := m.stageReplacements(ctx, changes, reason)
+
+	if err != nil {
+		return err
+	}
+
+	m.applyReplacements(ctx, staged, events)
+
+	return nil
+}
+
+// stageReplacements works on copies of the affected reviewer lists and
+// returns them with the events to log, or the first error. Nothing is
+// modified until applyReplacements is called, which keeps a failed batch from
+// being partially applied. Errors match the Postgres replaceReviewer.
+func (m *MemoryStorage) stageReplacements(ctx context.Context, changes []models.ReviewReassignment, reason string) (map[string][]memoryReviewer, []models.PREvent, error) {
+	staged := make(map[string][]memoryReviewer)
+	events := []models.PREvent{}
+
+	for _, change := range changes {
+		p, ok := m.prs[change.PullRequestID]
+
+		if !ok {
+			return nil, nil, ErrNotFound
+		}
+
+		if p.pr.Status != models.PRStatusOpen {
+			return nil, nil, ErrStatusConflict
+		}
+
+		reviewers, ok := staged[change.PullRequestID]
+		if !ok {
+			reviewers = slices.Clone(p.reviewers)
+		}
+
+		idx := reviewerIndex(reviewers, change.OldReviewerID)
+
+		if idx < 0 {
+			return nil, nil, ErrNotAssigned
+		}
+
+		reviewers = slices.Delete(reviewers, idx, idx+1)
+
+		events = append(events, models.PREvent{
+			PullRequestID: change.PullRequestID,
+			EventType:     models.EventReviewerUnassigned,
+			UserID:        change.OldReviewerID,
+			Reason:        reason,
+		})
+
+		if change.NewReviewerID != "" {
+			if reviewerIndex(reviewers, change.NewReviewerID) >= 0 {
+				return nil, nil, ErrStatusConflict
+			}
+
+			reviewers = append(reviewers, memoryReviewer{
+				reviewerID:   change.NewReviewerID,
+				fallbackTeam: change.FallbackTeam,
+				assignedBy:   auth.Actor(ctx),
+				assignedAt:   time.Now(),
+			})
+
+			assignReason := "replaces " + change.OldReviewerID
+			if change.FallbackTeam != "" {
+				assignReason += " from backup team " + change.FallbackTeam
+			}
+
+			events = append(events, models.PREvent{
+				PullRequestID: change.PullRequestID,
+				EventType:     models.EventReviewerAssigned,
+				UserID:        change.NewReviewerID,
+				Reason:        assignReason,
+			})
+		}
+
+		staged[change.PullRequestID] = reviewers
+	}
+
+	return staged, events, nil
+}
+
+func (m *MemoryStorage) applyReplacements(ctx context.Context, staged map[string][]memoryReviewer, events []models.PREvent) {
+	for prID, reviewers := range staged {
+		m.prs[prID].reviewers = reviewers
+	}
+
+	for _, event := range events {
+		m.appendEvent(ctx, event)
+	}
+}
+
+func (m *MemoryStorage) GetOpenPRsReviewedBy(ctx context.Context, reviewerIDs []string) ([]models.PullRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	prs := []models.PullRequest{}
+	for _, p := range m.sortedPRs() {
+		if p.pr.Status != models.PRStatusOpen || !p.reviewedByAny(reviewerIDs) {
+			continue
+		}
+
+		pr := models.PullRequest{
+			PullRequestID: p.pr.PullRequestID,
+			AuthorID:      p.pr.AuthorID,
+			Status:        models.PRStatusOpen,
+		}
+		for _, r := range p.reviewers {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, r.reviewerID)
+		}
+
+		prs = append(prs, pr)
+	}
+
+	return prs, nil
+}
+
+func (m *MemoryStorage) GetOpenReviewsByUser(ctx context.Context, userID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	prIDs := []string{}
+	for _, p := range m.sortedPRs() {
+		if p.pr.Status == models.PRStatusOpen && p.reviewedByAny([]string{userID}) {
+			prIDs = append(prIDs, p.pr.PullRequestID)
+		}
+	}
+
+	return prIDs, nil
+}
+
+func (m *MemoryStorage) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sorted := m.sortedPRs()
+	slices.Reverse(sorted)
+
+	result := []models.PullRequestShort{}
+	for _, p := range sorted {
+		if !p.reviewedByAny([]string{userID}) {
+			continue
+		}
+
+		result = append(result, models.PullRequestShort{
+			PullRequestID:   p.pr.PullRequestID,
+			PullRequestName: p.pr.PullRequestName,
+			AuthorID:        p.pr.AuthorID,
+			Status:          p.pr.Status,
+		})
+	}
+
+	return result, nil
+}
+
+func (m *MemoryStorage) GetActivePRsByAuthors(ctx context.Context, authorIDs []string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	prIDs := []string{}
+	for _, p := range m.sortedPRs() {
+		if p.pr.Status != models.PRStatusDraft && p.pr.Status != models.PRStatusOpen {
+			continue
+		}
+
+		if slices.Contains(authorIDs, p.pr.AuthorID) {
+			prIDs = append(prIDs, p.pr.PullRequestID)
+		}
+	}
+
+	return prIDs, nil
+}
+
+func (m *MemoryStorage) AddReview(ctx context.Context, prID string, review *models.Review) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.prs[prID]
+
+	if !ok || reviewerIndex(p.reviewers, review.ReviewerID) < 0 {
+		return ErrNotAssigned
+	}
+
+	if p.pr.Status != models.PRStatusOpen {
+		return ErrStatusConflict
+	}
+
+	now := time.Now()
+	p.reviews = append(p.reviews, models.Review{
+		ReviewerID:  review.ReviewerID,
+		Decision:    review.Decision,
+		Body:        review.Body,
+		SubmittedAt: &now,
+	})
+
+	m.appendEvent(ctx, models.PREvent{
+		PullRequestID: prID,
+		EventType:     models.EventReviewSubmitted,
+		UserID:        review.ReviewerID,
+		Reason:        review.Decision,
+	})
+
+	return nil
+}
+
+func (m *MemoryStorage) GetReviews(ctx context.Context, prID string) ([]models.Review, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.prs[prID]
+
+	if !ok {
+		return []models.Review{}, nil
+	}
+
+	return copyReviews(p.reviews), nil
+}
+
+func (m *MemoryStorage) GetPREvents(ctx context.Context, prID string) ([]models.PREvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := []models.PREvent{}
+	for _, event := range m.events {
+		if event.PullRequestID == prID {
+			event.CreatedAt = copyTime(event.CreatedAt)
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// appendEvent assigns the next event id and timestamp, like the BIGSERIAL
+// and DEFAULT columns of pr_events.
+func (m *MemoryStorage) appendEvent(ctx context.Context, event models.PREvent) {
+	if event.Actor == "" {
+		event.Actor = auth.Actor(ctx)
+	}
+
+	now := time.Now()
+	event.EventID = int64(len(m.events) + 1)
+	event.CreatedAt = &now
+	m.events = append(m.events, event)
+}
+
+func (m *MemoryStorage) GetReviewAssignmentsCount(ctx context.Context) (map[string]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, p := range m.prs {
+		if p.pr.Status != models.PRStatusOpen && p.pr.Status != models.PRStatusMerged {
+			continue
+		}
+
+		for _, r := range p.reviewers {
+			counts[r.reviewerID]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (m *MemoryStorage) GetOpenReviewLoad(ctx context.Context, teamName string) (map[string]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	load := make(map[string]int)
+	for id, u := range m.users {
+		if teamName != "" && u.user.TeamName == teamName {
+			load[id] = 0
+		}
+	}
+
+	for _, p := range m.prs {
+		if p.pr.Status != models.PRStatusOpen {
+			continue
+		}
+
+		for _, r := range p.reviewers {
+			if _, ok := load[r.reviewerID]; ok {
+				load[r.reviewerID]++
+			}
+		}
+	}
+
+	return load, nil
+}
+
+func (m *MemoryStorage) GetPRCountByStatus(ctx context.Context) (map[string]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, p := range m.prs {
+		counts[p.pr.Status]++
+	}
+
+	return counts, nil
+}
+
+// sortedPRs returns the PRs oldest first, ties broken by id.
+func (m *MemoryStorage) sortedPRs() []*memoryPR {
+	prs := make([]*memoryPR, 0, len(m.prs))
+	for _, p := range m.prs {
+		prs = append(prs, p)
+	}
+
+	sort.Slice(prs, func(i, j int) bool {
+		ci, cj := prs[i].pr.CreatedAt, prs[j].pr.CreatedAt
+		if !ci.Equal(*cj) {
+			return ci.Before(*cj)
+		}
+
+		return prs[i].pr.PullRequestID < prs[j].pr.PullRequestID
+	})
+
+	return prs
+}
+
+func (p *memoryPR) reviewedByAny(userIDs []string) bool {
+	for _, r := range p.reviewers {
+		if slices.Contains(userIDs, r.reviewerID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func reviewerIndex(reviewers []memoryReviewer, reviewerID string) int {
+	return slices.IndexFunc(reviewers, func(r memoryReviewer) bool {
+		return r.reviewerID == reviewerID
+	})
+}
+
+func copyReviews(reviews []models.Review) []models.Review {
+	result := make([]models.Review, len(reviews))
+	for i, review := range reviews {
+		review.SubmittedAt = copyTime(review.SubmittedAt)
+		result[i] = review
+	}
+
+	return result
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+
+	return &c
+}
+
+func (m *MemoryStorage) CreateToken(ctx context.Context, token *models.APIToken, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range m.tokens {
+		if t.hash == tokenHash {
+			return fmt.Errorf("token hash already exists")
+		}
+	}
+
+	now := time.Now()
+	m.lastTokenID++
+	token.TokenID = m.lastTokenID
+	token.CreatedAt = &now
+
+	stored := *token
+	stored.Token = ""
+	m.tokens = append(m.tokens, &memoryToken{token: stored, hash: tokenHash})
+
+	return nil
+}
+
+func (m *MemoryStorage) GetTokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, t := range m.tokens {
+		if t.hash == tokenHash {
+			token := t.token
+			return &token, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (m *MemoryStorage) ListTokens(ctx context.Context) ([]models.APIToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tokens := make([]models.APIToken, 0, len(m.tokens))
+	for _, t := range m.tokens {
+		tokens = append(tokens, t.token)
+	}
+
+	return tokens, nil
+}
+
+func (m *MemoryStorage) RevokeToken(ctx context.Context, tokenID int64) (*models.APIToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range m.tokens {
+		if t.token.TokenID != tokenID {
+			continue
+		}
+
+		if t.token.RevokedAt == nil {
+			now := time.Now()
+			t.token.RevokedAt = &now
+		}
+
+		token := t.token
+		return &token, nil
+	}
+
+	return nil, ErrNotFound
+}
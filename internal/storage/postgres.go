@@ -5,9 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/XSAM/otelsql"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 var (
@@ -15,23 +19,55 @@ var (
 	ErrNotFound    = errors.New("NOT_FOUND")
 	ErrPRExists    = errors.New("PR_EXISTS")
 	ErrNotAssigned = errors.New("NOT_ASSIGNED")
+	// ErrStatusConflict means the PR status, its reviewers or the team
+	// membership being changed was modified concurrently.
+	ErrStatusConflict = errors.New("STATUS_CONFLICT")
+	// ErrMemberExists means the user already belongs to a team.
+	ErrMemberExists = errors.New("MEMBER_EXISTS")
 )
 
 type PostgresStorage struct {
-	db *sql.DB
+	db     *sql.DB
+	logger *slog.Logger
 }
 
-func NewPostgresStorage(connString string) (*PostgresStorage, error) {
-	db, err := sql.Open("pgx", connString)
+// PoolOptions size the connection pool; see the matching sql.DB setters.
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+func DefaultPoolOptions() PoolOptions {
+	return PoolOptions{
+		MaxOpenConns:    25,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 5 * time.Minute,
+		ConnMaxIdleTime: 10 * time.Minute,
+	}
+}
+
+func NewPostgresStorage(connString string, pool PoolOptions, logger *slog.Logger) (*PostgresStorage, error) {
+	// Every statement gets a span carrying its SQL; rows iteration and
+	// connection resets would only add noise.
+	db, err := otelsql.Open("pgx", connString,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
-	db.SetConnMaxIdleTime(10 * time.Minute)
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -40,9 +76,35 @@ func NewPostgresStorage(connString string) (*PostgresStorage, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &PostgresStorage{db: db}, nil
+	return &PostgresStorage{db: db, logger: logger}, nil
+}
+
+// DB exposes the connection pool for schema migrations and health checks.
+func (s *PostgresStorage) DB() *sql.DB {
+	return s.db
+}
+
+// Ping checks that the database is reachable.
+func (s *PostgresStorage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// PoolStats returns the connection pool statistics.
+func (s *PostgresStorage) PoolStats() sql.DBStats {
+	return s.db.Stats()
 }
 
 func (s *PostgresStorage) Close() error {
 	return s.db.Close()
 }
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -3,9 +3,9 @@ package storage
 import (
 	"context"
 	"database/sql"
-	"log"
 	"time"
 
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
 	"github.com/Jersonmade/pr-reviewer-service/internal/models"
 )
 
@@ -17,7 +17,7 @@ func (s *PostgresStorage) CreatePR(ctx context.Context, pr *models.PullRequest)
 	}
 	defer func() {
 		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
-			log.Printf("tx rollback failed: %v", err)
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
 		}
 	}()
 
@@ -36,38 +36,143 @@ func (s *PostgresStorage) CreatePR(ctx context.Context, pr *models.PullRequest)
 	}
 
 	_, err = tx.ExecContext(ctx, `
-        INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-        VALUES ($1, $2, $3, $4, $5)
-    `, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, time.Now())
+        INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, time.Now(), nullString(auth.Actor(ctx)))
 
 	if err != nil {
 		return err
 	}
 
-	for _, reviewerID := range pr.AssignedReviewers {
-		_, err = tx.ExecContext(ctx, `
-            INSERT INTO pr_reviewers (pull_request_id, reviewer_id)
-            VALUES ($1, $2)
-        `, pr.PullRequestID, reviewerID)
+	err = insertEvent(ctx, tx, &models.PREvent{
+		PullRequestID: pr.PullRequestID,
+		EventType:     models.EventPRCreated,
+		UserID:        pr.AuthorID,
+		ToStatus:      pr.Status,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := assignReviewers(ctx, tx, pr.PullRequestID, pr.AssignedReviewers, pr.FallbackReviewers); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// assignReviewers inserts automatically selected reviewers and logs each
+// assignment, noting reviewers borrowed from a backup team.
+func assignReviewers(ctx context.Context, tx *sql.Tx, prID string, reviewers []string, fallback map[string]string) error {
+	for _, reviewerID := range reviewers {
+		_, err := tx.ExecContext(ctx, `
+            INSERT INTO pr_reviewers (pull_request_id, reviewer_id, fallback_team, assigned_by)
+            VALUES ($1, $2, $3, $4)
+        `, prID, reviewerID, nullString(fallback[reviewerID]), nullString(auth.Actor(ctx)))
+
+		if err != nil {
+			return err
+		}
+
+		reason := "auto-assigned"
+		if team := fallback[reviewerID]; team != "" {
+			reason = "auto-assigned from backup team " + team
+		}
+
+		err = insertEvent(ctx, tx, &models.PREvent{
+			PullRequestID: prID,
+			EventType:     models.EventReviewerAssigned,
+			UserID:        reviewerID,
+			Reason:        reason,
+		})
 
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	return nil
+}
+
+// changeStatus moves the PR to toStatus if its current status is one of from,
+// logging the transition. It returns ErrNotFound or ErrStatusConflict
+// otherwise. The row stays locked until the transaction ends.
+func changeStatus(ctx context.Context, tx *sql.Tx, prID, toStatus, reason string, from ...string) error {
+	var current string
+
+	err := tx.QueryRowContext(ctx,
+		"SELECT status FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE",
+		prID,
+	).Scan(&current)
+
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, status := range from {
+		if current == status {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		return ErrStatusConflict
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE pull_requests
+        SET status = $2,
+            merged_at = CASE WHEN $2 = 'MERGED' THEN CURRENT_TIMESTAMP ELSE merged_at END,
+            closed_at = CASE WHEN $2 = 'CLOSED' THEN CURRENT_TIMESTAMP ELSE NULL END
+        WHERE pull_request_id = $1
+    `, prID, toStatus)
+
+	if err != nil {
+		return err
+	}
+
+	return insertEvent(ctx, tx, &models.PREvent{
+		PullRequestID: prID,
+		EventType:     models.EventStatusChanged,
+		FromStatus:    current,
+		ToStatus:      toStatus,
+		Reason:        reason,
+	})
+}
+
+// querier is what reads need from *sql.DB and *sql.Tx, so that they can also
+// run inside a transaction.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 func (s *PostgresStorage) GetPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	return s.getPR(ctx, s.db, prID)
+}
+
+func (s *PostgresStorage) getPR(ctx context.Context, q querier, prID string) (*models.PullRequest, error) {
 	var pr models.PullRequest
 	var createdAt time.Time
-	var mergedAt sql.NullTime
+	var mergedAt, closedAt sql.NullTime
+	var forceMergeReason, createdBy, mergedBy sql.NullString
 
-	err := s.db.QueryRowContext(ctx, `
-        SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+	err := q.QueryRowContext(ctx, `
+        SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at,
+               force_merged, force_merge_reason, created_by, merged_by
         FROM pull_requests
         WHERE pull_request_id = $1
-    `, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt)
+    `, prID).Scan(
+		&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt, &closedAt,
+		&pr.ForceMerged, &forceMergeReason, &createdBy, &mergedBy,
+	)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
@@ -83,8 +188,16 @@ func (s *PostgresStorage) GetPR(ctx context.Context, prID string) (*models.PullR
 		pr.MergedAt = &mergedAt.Time
 	}
 
-	rows, err := s.db.QueryContext(ctx, `
-        SELECT reviewer_id
+	if closedAt.Valid {
+		pr.ClosedAt = &closedAt.Time
+	}
+
+	pr.ForceMergeReason = forceMergeReason.String
+	pr.CreatedBy = createdBy.String
+	pr.MergedBy = mergedBy.String
+
+	rows, err := q.QueryContext(ctx, `
+        SELECT reviewer_id, fallback_team, assigned_by
         FROM pr_reviewers
         WHERE pull_request_id = $1
         ORDER BY assigned_at
@@ -96,7 +209,7 @@ func (s *PostgresStorage) GetPR(ctx context.Context, prID string) (*models.PullR
 
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("rows close failed: %v", err)
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
 		}
 	}()
 
@@ -104,32 +217,171 @@ func (s *PostgresStorage) GetPR(ctx context.Context, prID string) (*models.PullR
 
 	for rows.Next() {
 		var reviewerID string
+		var fallbackTeam, assignedBy sql.NullString
 
-		if err := rows.Scan(&reviewerID); err != nil {
+		if err := rows.Scan(&reviewerID, &fallbackTeam, &assignedBy); err != nil {
 			return nil, err
 		}
 
 		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+
+		if fallbackTeam.Valid {
+			if pr.FallbackReviewers == nil {
+				pr.FallbackReviewers = make(map[string]string)
+			}
+			pr.FallbackReviewers[reviewerID] = fallbackTeam.String
+		}
+
+		if assignedBy.Valid {
+			if pr.AssignedBy == nil {
+				pr.AssignedBy = make(map[string]string)
+			}
+			pr.AssignedBy[reviewerID] = assignedBy.String
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	pr.Reviews, err = s.getReviews(ctx, q, prID)
+
+	if err != nil {
+		return nil, err
 	}
 
 	return &pr, nil
 }
 
-func (s *PostgresStorage) MergePR(ctx context.Context, prID string) (*models.PullRequest, error) {
-	_, err := s.db.ExecContext(ctx, `
+// MergePR merges an OPEN PR if check allows it. The PR, its reviewers and
+// reviews are locked and read in the merge's transaction, so check sees
+// exactly what gets merged.
+func (s *PostgresStorage) MergePR(ctx context.Context, prID string, check MergeCheck) (*models.PullRequest, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	var status string
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT status FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE",
+		prID,
+	).Scan(&status)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if status != models.PRStatusOpen {
+		return nil, ErrStatusConflict
+	}
+
+	pr, err := s.getPR(ctx, tx, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	forced, reason, err := check(pr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := changeStatus(ctx, tx, prID, models.PRStatusMerged, reason, models.PRStatusOpen); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
         UPDATE pull_requests
-        SET status = 'MERGED', merged_at = CURRENT_TIMESTAMP
-        WHERE pull_request_id = $1 AND status = 'OPEN'
-    `, prID)
+        SET force_merged = $2, force_merge_reason = $3, merged_by = $4
+        WHERE pull_request_id = $1
+    `, prID, forced, nullString(reason), nullString(auth.Actor(ctx)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetPR(ctx, prID)
+}
+
+// ClosePR closes an OPEN or DRAFT PR without merging it.
+func (s *PostgresStorage) ClosePR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	err = changeStatus(ctx, tx, prID, models.PRStatusClosed, "", models.PRStatusOpen, models.PRStatusDraft)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetPR(ctx, prID)
+}
+
+// OpenPR moves a PR from fromStatus to OPEN and assigns the given reviewers in
+// the same transaction. It is used both for reopening and leaving DRAFT.
+func (s *PostgresStorage) OpenPR(ctx context.Context, prID, fromStatus string, reviewers []string, fallback map[string]string) (*models.PullRequest, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 
 	if err != nil {
 		return nil, err
 	}
 
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	if err := changeStatus(ctx, tx, prID, models.PRStatusOpen, "", fromStatus); err != nil {
+		return nil, err
+	}
+
+	if err := assignReviewers(ctx, tx, prID, reviewers, fallback); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return s.GetPR(ctx, prID)
 }
 
-func (s *PostgresStorage) ReassignReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) error {
+// ReassignReviewer swaps oldReviewerID for newReviewerID. fallbackTeam is the
+// backup team the new reviewer was borrowed from, or empty. Both sides of the
+// swap are logged with the given reason.
+func (s *PostgresStorage) ReassignReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID, fallbackTeam, reason string) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 
 	if err != nil {
@@ -138,14 +390,125 @@ func (s *PostgresStorage) ReassignReviewer(ctx context.Context, prID, oldReviewe
 
 	defer func() {
 		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
-			log.Printf("tx rollback failed: %v", err)
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
 		}
 	}()
 
+	change := &models.ReviewReassignment{
+		PullRequestID: prID,
+		OldReviewerID: oldReviewerID,
+		NewReviewerID: newReviewerID,
+		FallbackTeam:  fallbackTeam,
+	}
+
+	if err := replaceReviewer(ctx, tx, change, reason); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// replaceReviewer removes the old reviewer from an OPEN PR and, unless
+// NewReviewerID is empty, assigns the replacement. It returns
+// ErrStatusConflict if the PR is no longer OPEN or the replacement got
+// assigned meanwhile, and ErrNotAssigned if the old reviewer was already
+// removed.
+func replaceReviewer(ctx context.Context, tx *sql.Tx, change *models.ReviewReassignment, reason string) error {
+	return replaceReviewers(ctx, tx, []models.ReviewReassignment{*change}, reason)
+}
+
+// replaceReviewers is replaceReviewer for many changes at once, in a fixed
+// number of statements however many users and PRs they touch.
+func replaceReviewers(ctx context.Context, tx *sql.Tx, changes []models.ReviewReassignment, reason string) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	prIDs := make([]string, 0, len(changes))
+	oldIDs := make([]string, 0, len(changes))
+	var addPRs, addIDs, addTeams []string
+	events := make([]models.PREvent, 0, 2*len(changes))
+
+	for _, change := range changes {
+		prIDs = append(prIDs, change.PullRequestID)
+		oldIDs = append(oldIDs, change.OldReviewerID)
+
+		events = append(events, models.PREvent{
+			PullRequestID: change.PullRequestID,
+			EventType:     models.EventReviewerUnassigned,
+			UserID:        change.OldReviewerID,
+			Reason:        reason,
+		})
+
+		if change.NewReviewerID == "" {
+			continue
+		}
+
+		addPRs = append(addPRs, change.PullRequestID)
+		addIDs = append(addIDs, change.NewReviewerID)
+		addTeams = append(addTeams, change.FallbackTeam)
+
+		assignReason := "replaces " + change.OldReviewerID
+		if change.FallbackTeam != "" {
+			assignReason += " from backup team " + change.FallbackTeam
+		}
+
+		events = append(events, models.PREvent{
+			PullRequestID: change.PullRequestID,
+			EventType:     models.EventReviewerAssigned,
+			UserID:        change.NewReviewerID,
+			Reason:        assignReason,
+		})
+	}
+
+	// Locked in a fixed order so that concurrent batches cannot deadlock.
+	rows, err := tx.QueryContext(ctx, `
+        SELECT pull_request_id, status
+        FROM pull_requests
+        WHERE pull_request_id = ANY($1)
+        ORDER BY pull_request_id
+        FOR UPDATE
+    `, prIDs)
+
+	if err != nil {
+		return err
+	}
+
+	statuses := make(map[string]string, len(prIDs))
+	for rows.Next() {
+		var prID, status string
+		if err := rows.Scan(&prID, &status); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		statuses[prID] = status
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, prID := range prIDs {
+		status, ok := statuses[prID]
+
+		if !ok {
+			return ErrNotFound
+		}
+
+		if status != models.PRStatusOpen {
+			return ErrStatusConflict
+		}
+	}
+
 	result, err := tx.ExecContext(ctx, `
-        DELETE FROM pr_reviewers
-        WHERE pull_request_id = $1 AND reviewer_id = $2
-    `, prID, oldReviewerID)
+        DELETE FROM pr_reviewers r
+        USING unnest($1::text[], $2::text[]) AS c(pull_request_id, reviewer_id)
+        WHERE r.pull_request_id = c.pull_request_id AND r.reviewer_id = c.reviewer_id
+    `, prIDs, oldIDs)
 
 	if err != nil {
 		return err
@@ -157,20 +520,104 @@ func (s *PostgresStorage) ReassignReviewer(ctx context.Context, prID, oldReviewe
 		return err
 	}
 
-	if rowsAffected == 0 {
+	if rowsAffected != int64(len(changes)) {
 		return ErrNotAssigned
 	}
 
-	_, err = tx.ExecContext(ctx, `
-        INSERT INTO pr_reviewers (pull_request_id, reviewer_id)
-        VALUES ($1, $2)
-    `, prID, newReviewerID)
+	if len(addPRs) > 0 {
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO pr_reviewers (pull_request_id, reviewer_id, fallback_team, assigned_by)
+            SELECT pull_request_id, reviewer_id, NULLIF(fallback_team, ''), $4::text
+            FROM unnest($1::text[], $2::text[], $3::text[]) AS c(pull_request_id, reviewer_id, fallback_team)
+        `, addPRs, addIDs, addTeams, nullString(auth.Actor(ctx)))
+
+		if isUniqueViolation(err) {
+			return ErrStatusConflict
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return insertEvents(ctx, tx, events)
+}
+
+// GetOpenPRsReviewedBy returns every OPEN PR reviewed by at least one of the
+// given users, with its full reviewer list, oldest first, in one round trip.
+func (s *PostgresStorage) GetOpenPRsReviewedBy(ctx context.Context, reviewerIDs []string) ([]models.PullRequest, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT p.pull_request_id, p.author_id, r.reviewer_id
+        FROM pull_requests p
+        INNER JOIN pr_reviewers r ON p.pull_request_id = r.pull_request_id
+        WHERE p.status = 'OPEN' AND p.pull_request_id IN (
+            SELECT pull_request_id FROM pr_reviewers WHERE reviewer_id = ANY($1)
+        )
+        ORDER BY p.created_at, p.pull_request_id, r.assigned_at
+    `, reviewerIDs)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return tx.Commit()
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	prs := []models.PullRequest{}
+	for rows.Next() {
+		var prID, authorID, reviewerID string
+		if err := rows.Scan(&prID, &authorID, &reviewerID); err != nil {
+			return nil, err
+		}
+
+		if len(prs) == 0 || prs[len(prs)-1].PullRequestID != prID {
+			prs = append(prs, models.PullRequest{
+				PullRequestID: prID,
+				AuthorID:      authorID,
+				Status:        models.PRStatusOpen,
+			})
+		}
+
+		last := &prs[len(prs)-1]
+		last.AssignedReviewers = append(last.AssignedReviewers, reviewerID)
+	}
+
+	return prs, rows.Err()
+}
+
+// GetOpenReviewsByUser returns the ids of OPEN PRs the user is reviewing.
+func (s *PostgresStorage) GetOpenReviewsByUser(ctx context.Context, userID string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT p.pull_request_id
+        FROM pull_requests p
+        INNER JOIN pr_reviewers r ON p.pull_request_id = r.pull_request_id
+        WHERE r.reviewer_id = $1 AND p.status = 'OPEN'
+        ORDER BY p.created_at
+    `, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	prIDs := []string{}
+	for rows.Next() {
+		var prID string
+		if err := rows.Scan(&prID); err != nil {
+			return nil, err
+		}
+		prIDs = append(prIDs, prID)
+	}
+
+	return prIDs, rows.Err()
 }
 
 func (s *PostgresStorage) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
@@ -188,7 +635,7 @@ func (s *PostgresStorage) GetPRsByReviewer(ctx context.Context, userID string) (
 
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("rows close failed: %v", err)
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
 		}
 	}()
 
@@ -205,3 +652,35 @@ func (s *PostgresStorage) GetPRsByReviewer(ctx context.Context, userID string) (
 
 	return result, nil
 }
+
+// GetActivePRsByAuthors returns the ids of DRAFT and OPEN PRs authored by
+// any of the users, oldest first.
+func (s *PostgresStorage) GetActivePRsByAuthors(ctx context.Context, authorIDs []string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT pull_request_id
+        FROM pull_requests
+        WHERE author_id = ANY($1) AND status IN ('DRAFT', 'OPEN')
+        ORDER BY created_at, pull_request_id
+    `, authorIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	prIDs := []string{}
+	for rows.Next() {
+		var prID string
+		if err := rows.Scan(&prID); err != nil {
+			return nil, err
+		}
+		prIDs = append(prIDs, prID)
+	}
+
+	return prIDs, rows.Err()
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+)
+
+// AddReview records a review decision. The insert only happens while the
+// reviewer is assigned to the PR, otherwise ErrNotAssigned is returned, and
+// while the PR is OPEN, otherwise ErrStatusConflict is. The PR row is locked
+// so that a merge in progress sees the review or refuses it.
+func (s *PostgresStorage) AddReview(ctx context.Context, prID string, review *models.Review) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	var status string
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT status FROM pull_requests WHERE pull_request_id = $1 FOR SHARE",
+		prID,
+	).Scan(&status)
+
+	if err == sql.ErrNoRows {
+		return ErrNotAssigned
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if status != models.PRStatusOpen {
+		return ErrStatusConflict
+	}
+
+	res, err := tx.ExecContext(ctx, `
+        INSERT INTO pr_reviews (pull_request_id, reviewer_id, decision, body)
+        SELECT $1, $2, $3, $4
+        WHERE EXISTS (
+            SELECT 1 FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2
+        )
+    `, prID, review.ReviewerID, review.Decision, review.Body)
+
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotAssigned
+	}
+
+	err = insertEvent(ctx, tx, &models.PREvent{
+		PullRequestID: prID,
+		EventType:     models.EventReviewSubmitted,
+		UserID:        review.ReviewerID,
+		Reason:        review.Decision,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStorage) GetReviews(ctx context.Context, prID string) ([]models.Review, error) {
+	return s.getReviews(ctx, s.db, prID)
+}
+
+func (s *PostgresStorage) getReviews(ctx context.Context, q querier, prID string) ([]models.Review, error) {
+	rows, err := q.QueryContext(ctx, `
+        SELECT reviewer_id, decision, body, submitted_at
+        FROM pr_reviews
+        WHERE pull_request_id = $1
+        ORDER BY submitted_at, review_id
+    `, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	reviews := []models.Review{}
+	for rows.Next() {
+		var review models.Review
+		var submittedAt time.Time
+
+		if err := rows.Scan(&review.ReviewerID, &review.Decision, &review.Body, &submittedAt); err != nil {
+			return nil, err
+		}
+
+		review.SubmittedAt = &submittedAt
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
@@ -2,14 +2,15 @@ package storage
 
 import (
 	"context"
-	"log"
 )
 
 func (s *PostgresStorage) GetReviewAssignmentsCount(ctx context.Context) (map[string]int, error) {
 	rows, err := s.db.QueryContext(ctx, `
-        SELECT reviewer_id, COUNT(*)
-        FROM pr_reviewers
-        GROUP BY reviewer_id
+        SELECT r.reviewer_id, COUNT(*)
+        FROM pr_reviewers r
+        INNER JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+        WHERE p.status IN ('OPEN', 'MERGED')
+        GROUP BY r.reviewer_id
     `)
 
 	if err != nil {
@@ -18,7 +19,7 @@ func (s *PostgresStorage) GetReviewAssignmentsCount(ctx context.Context) (map[st
 
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("rows close failed: %v", err)
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
 		}
 	}()
 
@@ -33,3 +34,67 @@ func (s *PostgresStorage) GetReviewAssignmentsCount(ctx context.Context) (map[st
 	}
 	return counts, nil
 }
+
+// GetOpenReviewLoad returns, for every member of the team, how many OPEN pull
+// requests they are currently assigned to review. Members without open
+// reviews are reported with zero.
+func (s *PostgresStorage) GetOpenReviewLoad(ctx context.Context, teamName string) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT u.user_id, COUNT(p.pull_request_id)
+        FROM users u
+        LEFT JOIN pr_reviewers r ON r.reviewer_id = u.user_id
+        LEFT JOIN pull_requests p ON p.pull_request_id = r.pull_request_id AND p.status = 'OPEN'
+        WHERE u.team_name = $1
+        GROUP BY u.user_id
+    `, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	load := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+		load[userID] = count
+	}
+	return load, rows.Err()
+}
+
+func (s *PostgresStorage) GetPRCountByStatus(ctx context.Context) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT status, COUNT(*)
+        FROM pull_requests
+        GROUP BY status
+    `)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
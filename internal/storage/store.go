@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+)
+
+// Store is everything the services need from persistence. PostgresStorage is
+// the production implementation; MemoryStorage mirrors its semantics and
+// sentinel errors for unit tests and local demos.
+type Store interface {
+	TeamRepository
+	UserRepository
+	PRRepository
+	StatsRepository
+	TokenRepository
+
+	Close() error
+}
+
+type TeamRepository interface {
+	CreateTeam(ctx context.Context, team *models.Team) error
+	GetTeam(ctx context.Context, teamName string) (*models.Team, error)
+	GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, error)
+	GetBackupTeams(ctx context.Context, teamName string) ([]string, error)
+	UpdateTeamSettings(ctx context.Context, settings *models.TeamSettings) error
+	AddTeamMembers(ctx context.Context, teamName string, members []models.TeamMember) error
+	RemoveTeamMembers(ctx context.Context, teamName string, userIDs []string, changes []models.ReviewReassignment) error
+	TransferUser(ctx context.Context, userID, fromTeam, toTeam string, changes []models.ReviewReassignment) (*models.User, error)
+	RenameTeam(ctx context.Context, teamName, newName string) error
+	DeleteTeam(ctx context.Context, teamName, targetTeam string) ([]string, error)
+}
+
+type UserRepository interface {
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	GetUsersByIDs(ctx context.Context, userIDs []string) ([]models.User, error)
+	UpdateUserActive(ctx context.Context, userID string, isActive bool) (*models.User, error)
+	UpdateUserActiveWithReassignments(ctx context.Context, userID string, isActive bool, changes []models.ReviewReassignment) (*models.User, error)
+	DeactivateUsersWithReassignments(ctx context.Context, userIDs []string, changes []models.ReviewReassignment) error
+	GetActiveTeamMembers(ctx context.Context, teamName, excludeUserID string, excludeReviewers []string) ([]string, error)
+	GetReviewWeights(ctx context.Context, userIDs []string) (map[string]int, error)
+	GetLastAssignedAt(ctx context.Context, userIDs []string) (map[string]time.Time, error)
+}
+
+// MergeCheck is given the PR about to be merged, as of the merge itself. It
+// returns an error to refuse the merge, or whether the merge bypasses the
+// team's merge rules and the reason kept alongside for auditing.
+type MergeCheck func(pr *models.PullRequest) (forced bool, reason string, err error)
+
+// PRRepository also covers reviews and the PR event log, which are always
+// read and written alongside their pull request.
+type PRRepository interface {
+	CreatePR(ctx context.Context, pr *models.PullRequest) error
+	GetPR(ctx context.Context, prID string) (*models.PullRequest, error)
+	MergePR(ctx context.Context, prID string, check MergeCheck) (*models.PullRequest, error)
+	ClosePR(ctx context.Context, prID string) (*models.PullRequest, error)
+	OpenPR(ctx context.Context, prID, fromStatus string, reviewers []string, fallback map[string]string) (*models.PullRequest, error)
+	ReassignReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID, fallbackTeam, reason string) error
+	GetOpenPRsReviewedBy(ctx context.Context, reviewerIDs []string) ([]models.PullRequest, error)
+	GetOpenReviewsByUser(ctx context.Context, userID string) ([]string, error)
+	GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error)
+	GetActivePRsByAuthors(ctx context.Context, authorIDs []string) ([]string, error)
+
+	AddReview(ctx context.Context, prID string, review *models.Review) error
+	GetReviews(ctx context.Context, prID string) ([]models.Review, error)
+	GetPREvents(ctx context.Context, prID string) ([]models.PREvent, error)
+}
+
+type StatsRepository interface {
+	GetReviewAssignmentsCount(ctx context.Context) (map[string]int, error)
+	GetOpenReviewLoad(ctx context.Context, teamName string) (map[string]int, error)
+	GetPRCountByStatus(ctx context.Context) (map[string]int, error)
+}
+
+// TokenRepository stores API tokens by the hash of their secret.
+type TokenRepository interface {
+	CreateToken(ctx context.Context, token *models.APIToken, tokenHash string) error
+	GetTokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error)
+	ListTokens(ctx context.Context) ([]models.APIToken, error)
+	RevokeToken(ctx context.Context, tokenID int64) (*models.APIToken, error)
+}
+
+var (
+	_ Store = (*PostgresStorage)(nil)
+	_ Store = (*MemoryStorage)(nil)
+)
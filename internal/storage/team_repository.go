@@ -3,8 +3,8 @@ package storage
 import (
 	"context"
 	"database/sql"
-	"log"
 
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
 	"github.com/Jersonmade/pr-reviewer-service/internal/models"
 )
 
@@ -16,7 +16,7 @@ func (s *PostgresStorage) CreateTeam(ctx context.Context, team *models.Team) err
 	}
 	defer func() {
 		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
-			log.Printf("tx rollback failed: %v", err)
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
 		}
 	}()
 
@@ -36,51 +36,39 @@ func (s *PostgresStorage) CreateTeam(ctx context.Context, team *models.Team) err
 	}
 
 	_, err = tx.ExecContext(ctx,
-		"INSERT INTO teams (team_name) VALUES ($1)",
-		team.TeamName,
+		`INSERT INTO teams (
+			team_name, reviewer_strategy, min_reviewers, max_reviewers,
+			required_approvals, block_on_changes_requested, require_all_reviewers, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		team.TeamName, team.ReviewerStrategy, team.MinReviewers, team.MaxReviewers,
+		team.RequiredApprovals, team.BlockOnChangesRequested, team.RequireAllReviewers,
+		nullString(auth.Actor(ctx)),
 	)
 
 	if err != nil {
 		return err
 	}
 
-	for _, member := range team.Members {
-		_, err = tx.ExecContext(ctx, `
-			INSERT INTO users (user_id, username, team_name, is_active)
-			VALUES ($1, $2, $3, $4)
-			ON CONFLICT (user_id) DO UPDATE SET
-				username = EXCLUDED.username,
-				team_name = EXCLUDED.team_name,
-				is_active = EXCLUDED.is_active,
-				updated_at = CURRENT_TIMESTAMP
-			`, member.UserID, member.Username, team.TeamName, member.IsActive)
+	if err := addMembers(ctx, tx, team.TeamName, team.Members); err != nil {
+		return err
+	}
 
-		if err != nil {
-			return err
-		}
+	if err := setBackupTeams(ctx, tx, team.TeamName, team.BackupTeams); err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
 func (s *PostgresStorage) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
-	var exists bool
-
-	err := s.db.QueryRowContext(ctx,
-		"SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)",
-		teamName,
-	).Scan(&exists)
+	settings, err := s.GetTeamSettings(ctx, teamName)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if !exists {
-		return nil, ErrNotFound
-	}
-
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT user_id, username, is_active
+		SELECT user_id, username, is_active, review_weight
 		FROM users
 		WHERE team_name = $1
 		ORDER BY username
@@ -92,7 +80,7 @@ func (s *PostgresStorage) GetTeam(ctx context.Context, teamName string) (*models
 
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("rows close failed: %v", err)
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
 		}
 	}()
 
@@ -100,7 +88,7 @@ func (s *PostgresStorage) GetTeam(ctx context.Context, teamName string) (*models
 	for rows.Next() {
 		var m models.TeamMember
 
-		if err := rows.Scan(&m.UserID, &m.Username, &m.IsActive); err != nil {
+		if err := rows.Scan(&m.UserID, &m.Username, &m.IsActive, &m.ReviewWeight); err != nil {
 			return nil, err
 		}
 
@@ -108,7 +96,360 @@ func (s *PostgresStorage) GetTeam(ctx context.Context, teamName string) (*models
 	}
 
 	return &models.Team{
-		TeamName: teamName,
-		Members:  members,
+		TeamName:                teamName,
+		ReviewerStrategy:        settings.ReviewerStrategy,
+		MinReviewers:            settings.MinReviewers,
+		MaxReviewers:            settings.MaxReviewers,
+		RequiredApprovals:       settings.RequiredApprovals,
+		BlockOnChangesRequested: settings.BlockOnChangesRequested,
+		RequireAllReviewers:     settings.RequireAllReviewers,
+		BackupTeams:             settings.BackupTeams,
+		Members:                 members,
+		CreatedBy:               settings.CreatedBy,
 	}, nil
 }
+
+func (s *PostgresStorage) GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	var settings models.TeamSettings
+	var createdBy sql.NullString
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT team_name, reviewer_strategy, min_reviewers, max_reviewers,
+			required_approvals, block_on_changes_requested, require_all_reviewers, created_by
+		FROM teams
+		WHERE team_name = $1
+	`, teamName).Scan(
+		&settings.TeamName, &settings.ReviewerStrategy, &settings.MinReviewers, &settings.MaxReviewers,
+		&settings.RequiredApprovals, &settings.BlockOnChangesRequested, &settings.RequireAllReviewers, &createdBy,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	settings.CreatedBy = createdBy.String
+	settings.BackupTeams, err = s.GetBackupTeams(ctx, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// GetBackupTeams returns the team's backup teams in priority order.
+func (s *PostgresStorage) GetBackupTeams(ctx context.Context, teamName string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT backup_team_name
+		FROM team_backups
+		WHERE team_name = $1
+		ORDER BY priority
+	`, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	backups := []string{}
+	for rows.Next() {
+		var backup string
+
+		if err := rows.Scan(&backup); err != nil {
+			return nil, err
+		}
+
+		backups = append(backups, backup)
+	}
+
+	return backups, rows.Err()
+}
+
+func setBackupTeams(ctx context.Context, tx *sql.Tx, teamName string, backups []string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM team_backups WHERE team_name = $1", teamName)
+
+	if err != nil {
+		return err
+	}
+
+	for i, backup := range backups {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO team_backups (team_name, backup_team_name, priority)
+			VALUES ($1, $2, $3)
+		`, teamName, backup, i)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *PostgresStorage) UpdateTeamSettings(ctx context.Context, settings *models.TeamSettings) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE teams
+		SET reviewer_strategy = $2, min_reviewers = $3, max_reviewers = $4,
+			required_approvals = $5, block_on_changes_requested = $6, require_all_reviewers = $7
+		WHERE team_name = $1
+	`, settings.TeamName, settings.ReviewerStrategy, settings.MinReviewers, settings.MaxReviewers,
+		settings.RequiredApprovals, settings.BlockOnChangesRequested, settings.RequireAllReviewers)
+
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	if err := setBackupTeams(ctx, tx, settings.TeamName, settings.BackupTeams); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RenameTeam changes the team's name and returns ErrTeamExists if it is
+// taken. Users, backup links and tokens follow through ON UPDATE CASCADE;
+// fallback_team on reviewer rows is a plain label and is rewritten here.
+// Logged events keep the name that was current when they happened.
+func (s *PostgresStorage) RenameTeam(ctx context.Context, teamName, newName string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, "UPDATE teams SET team_name = $2 WHERE team_name = $1", teamName, newName)
+
+	if isUniqueViolation(err) {
+		return ErrTeamExists
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE pr_reviewers SET fallback_team = $2 WHERE fallback_team = $1",
+		teamName, newName,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	err = insertEvent(ctx, tx, &models.PREvent{
+		EventType: models.EventTeamRenamed,
+		FromTeam:  teamName,
+		ToTeam:    newName,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteTeam deletes the team and returns the users that belonged to it.
+// They are moved to targetTeam, or, if it is empty, left without a team and
+// deactivated; in that case it returns ErrStatusConflict when any of them
+// authors a DRAFT or OPEN PR or reviews an OPEN one. Backup links to and from
+// the team are dropped.
+func (s *PostgresStorage) DeleteTeam(ctx context.Context, teamName, targetTeam string) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT team_name FROM teams WHERE team_name = $1 FOR UPDATE",
+		teamName,
+	).Scan(new(string))
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if targetTeam == "" {
+		var busy bool
+
+		err = tx.QueryRowContext(ctx, `
+			SELECT EXISTS(
+				SELECT 1 FROM pull_requests pr
+				JOIN users u ON u.user_id = pr.author_id
+				WHERE u.team_name = $1 AND pr.status IN ('DRAFT', 'OPEN')
+			) OR EXISTS(
+				SELECT 1 FROM pr_reviewers prr
+				JOIN users u ON u.user_id = prr.reviewer_id
+				JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+				WHERE u.team_name = $1 AND pr.status = 'OPEN'
+			)
+		`, teamName).Scan(&busy)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if busy {
+			return nil, ErrStatusConflict
+		}
+	} else {
+		err = tx.QueryRowContext(ctx,
+			"SELECT team_name FROM teams WHERE team_name = $1 FOR SHARE",
+			targetTeam,
+		).Scan(new(string))
+
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	members, err := s.teamMemberIDs(ctx, tx, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if targetTeam == "" {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users
+			SET team_name = NULL, is_active = false, updated_at = CURRENT_TIMESTAMP
+			WHERE team_name = $1
+		`, teamName)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users
+			SET team_name = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE team_name = $1
+		`, teamName, targetTeam)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range members {
+		event := &models.PREvent{
+			EventType: models.EventMemberRemoved,
+			UserID:    userID,
+			FromTeam:  teamName,
+		}
+
+		if targetTeam != "" {
+			event.EventType = models.EventMemberTransferred
+			event.ToTeam = targetTeam
+		}
+
+		if err := insertEvent(ctx, tx, event); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM teams WHERE team_name = $1", teamName); err != nil {
+		return nil, err
+	}
+
+	err = insertEvent(ctx, tx, &models.PREvent{
+		EventType: models.EventTeamDeleted,
+		FromTeam:  teamName,
+		ToTeam:    targetTeam,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (s *PostgresStorage) teamMemberIDs(ctx context.Context, tx *sql.Tx, teamName string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT user_id FROM users WHERE team_name = $1 ORDER BY user_id FOR UPDATE",
+		teamName,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	userIDs := []string{}
+	for rows.Next() {
+		var userID string
+
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+)
+
+const tokenColumns = `token_id, name, role, team_name, user_id, created_at, revoked_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanToken(row rowScanner) (*models.APIToken, error) {
+	var token models.APIToken
+	var teamName, userID sql.NullString
+	var createdAt time.Time
+	var revokedAt sql.NullTime
+
+	err := row.Scan(&token.TokenID, &token.Name, &token.Role, &teamName, &userID, &createdAt, &revokedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	token.TeamName = teamName.String
+	token.UserID = userID.String
+	token.CreatedAt = &createdAt
+
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return &token, nil
+}
+
+// CreateToken stores the token under tokenHash and fills in its ID and
+// creation time. The team and user it is scoped to must exist.
+func (s *PostgresStorage) CreateToken(ctx context.Context, token *models.APIToken, tokenHash string) error {
+	var createdAt time.Time
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO api_tokens (name, token_hash, role, team_name, user_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING token_id, created_at
+	`,
+		token.Name, tokenHash, token.Role, nullString(token.TeamName), nullString(token.UserID),
+	).Scan(&token.TokenID, &createdAt)
+
+	if err != nil {
+		return err
+	}
+
+	token.CreatedAt = &createdAt
+
+	return nil
+}
+
+// GetTokenByHash returns the token whether or not it was revoked.
+func (s *PostgresStorage) GetTokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	token, err := scanToken(s.db.QueryRowContext(ctx,
+		"SELECT "+tokenColumns+" FROM api_tokens WHERE token_hash = $1",
+		tokenHash,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
+	return token, err
+}
+
+func (s *PostgresStorage) ListTokens(ctx context.Context) ([]models.APIToken, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+tokenColumns+" FROM api_tokens ORDER BY token_id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		token, err := scanToken(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// RevokeToken marks the token revoked. Revoking it again keeps the original
+// revocation time.
+func (s *PostgresStorage) RevokeToken(ctx context.Context, tokenID int64) (*models.APIToken, error) {
+	token, err := scanToken(s.db.QueryRowContext(ctx, `
+		UPDATE api_tokens
+		SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP)
+		WHERE token_id = $1
+		RETURNING `+tokenColumns,
+		tokenID,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
+	return token, err
+}
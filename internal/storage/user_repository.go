@@ -4,19 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"time"
 
 	"github.com/Jersonmade/pr-reviewer-service/internal/models"
 )
 
 func (s *PostgresStorage) GetUser(ctx context.Context, userID string) (*models.User, error) {
 	var user models.User
+	var teamName sql.NullString
 
 	err := s.db.QueryRowContext(ctx, `
 		SELECT user_id, username, team_name, is_active
 		FROM users
 		WHERE user_id = $1
-	`, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+	`, userID).Scan(&user.UserID, &user.Username, &teamName, &user.IsActive)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
@@ -26,11 +27,32 @@ func (s *PostgresStorage) GetUser(ctx context.Context, userID string) (*models.U
 		return nil, err
 	}
 
+	user.TeamName = teamName.String
+
 	return &user, nil
 }
 
 func (s *PostgresStorage) UpdateUserActive(ctx context.Context, userID string, isActive bool) (*models.User, error) {
-	res, err := s.db.ExecContext(ctx, `
+	return s.UpdateUserActiveWithReassignments(ctx, userID, isActive, nil)
+}
+
+// UpdateUserActiveWithReassignments flips the user's flag and applies the
+// review changes in a single transaction, so a deactivated user is never left
+// half-removed from their reviews.
+func (s *PostgresStorage) UpdateUserActiveWithReassignments(ctx context.Context, userID string, isActive bool, changes []models.ReviewReassignment) (*models.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
 		UPDATE users
 		SET is_active = $1, updated_at = CURRENT_TIMESTAMP
 		WHERE user_id = $2
@@ -50,6 +72,23 @@ func (s *PostgresStorage) UpdateUserActive(ctx context.Context, userID string, i
 		return nil, ErrNotFound
 	}
 
+	eventType := models.EventUserDeactivated
+	if isActive {
+		eventType = models.EventUserActivated
+	}
+
+	if err := insertEvent(ctx, tx, &models.PREvent{EventType: eventType, UserID: userID}); err != nil {
+		return nil, err
+	}
+
+	if err := replaceReviewers(ctx, tx, changes, "reviewer deactivated"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return s.GetUser(ctx, userID)
 }
 
@@ -81,7 +120,7 @@ func (s *PostgresStorage) GetActiveTeamMembers(ctx context.Context, teamName, ex
 
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("rows close failed: %v", err)
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
 		}
 	}()
 
@@ -96,3 +135,137 @@ func (s *PostgresStorage) GetActiveTeamMembers(ctx context.Context, teamName, ex
 
 	return candidates, nil
 }
+
+func (s *PostgresStorage) GetReviewWeights(ctx context.Context, userIDs []string) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, review_weight
+		FROM users
+		WHERE user_id = ANY($1)
+	`, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	weights := make(map[string]int, len(userIDs))
+	for rows.Next() {
+		var userID string
+		var weight int
+		if err := rows.Scan(&userID, &weight); err != nil {
+			return nil, err
+		}
+		weights[userID] = weight
+	}
+
+	return weights, rows.Err()
+}
+
+func (s *PostgresStorage) GetLastAssignedAt(ctx context.Context, userIDs []string) (map[string]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT reviewer_id, MAX(assigned_at)
+		FROM pr_reviewers
+		WHERE reviewer_id = ANY($1)
+		GROUP BY reviewer_id
+	`, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	lastAssigned := make(map[string]time.Time, len(userIDs))
+	for rows.Next() {
+		var userID string
+		var assignedAt time.Time
+		if err := rows.Scan(&userID, &assignedAt); err != nil {
+			return nil, err
+		}
+		lastAssigned[userID] = assignedAt
+	}
+
+	return lastAssigned, rows.Err()
+}
+
+func (s *PostgresStorage) GetUsersByIDs(ctx context.Context, userIDs []string) ([]models.User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, username, team_name, is_active
+		FROM users
+		WHERE user_id = ANY($1)
+	`, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.logger.ErrorContext(ctx, "rows close failed", "error", err)
+		}
+	}()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		var teamName sql.NullString
+		if err := rows.Scan(&user.UserID, &user.Username, &teamName, &user.IsActive); err != nil {
+			return nil, err
+		}
+		user.TeamName = teamName.String
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// DeactivateUsersWithReassignments deactivates all users and applies the
+// review changes in one transaction, with a fixed number of statements so
+// that the locks are held briefly even for hundreds of users.
+func (s *PostgresStorage) DeactivateUsersWithReassignments(ctx context.Context, userIDs []string, changes []models.ReviewReassignment) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.logger.ErrorContext(ctx, "tx rollback failed", "error", err)
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ANY($1)
+	`, userIDs)
+
+	if err != nil {
+		return err
+	}
+
+	events := make([]models.PREvent, len(userIDs))
+	for i, userID := range userIDs {
+		events[i] = models.PREvent{EventType: models.EventUserDeactivated, UserID: userID}
+	}
+
+	if err := insertEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	if err := replaceReviewers(ctx, tx, changes, "reviewer deactivated"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
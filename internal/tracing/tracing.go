@@ -0,0 +1,114 @@
+// Package tracing configures OpenTelemetry. Spans are started by the HTTP
+// middleware, by the services and, through the instrumented database/sql
+// driver, for every SQL statement; Setup decides where they are exported.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// ServiceName is reported as service.name unless OTEL_SERVICE_NAME is set.
+const ServiceName = "pr-reviewer-service"
+
+// Exporters accepted by Setup.
+const (
+	ExporterOff    = "off"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator. endpoint is the OTLP/HTTP collector URL; when empty the
+// standard OTEL_EXPORTER_OTLP_* variables apply. Sampling follows
+// OTEL_TRACES_SAMPLER and defaults to sampling everything. The returned
+// function flushes pending spans and must be called before exiting.
+func Setup(ctx context.Context, exporter, endpoint string, stdout io.Writer) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+
+	switch exporter {
+	case ExporterOff:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(stdout))
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(endpoint))
+		}
+
+		spanExporter, err = otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporter, err)
+	}
+
+	// Detectors listed later win, so OTEL_SERVICE_NAME and
+	// OTEL_RESOURCE_ATTRIBUTES override the built-in service name.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Middleware starts a server span for every request to mux, named after
+// the matched route, and continues the trace of an incoming traceparent
+// header. Requests to the untraced routes, such as probes, are skipped.
+func Middleware(mux *http.ServeMux, next http.Handler, untraced ...string) http.Handler {
+	skip := make(map[string]bool, len(untraced))
+	for _, route := range untraced {
+		skip[route] = true
+	}
+
+	route := func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		if pattern == "" {
+			return "unmatched"
+		}
+
+		return pattern
+	}
+
+	return otelhttp.NewHandler(next, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + route(r)
+		}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !skip[route(r)]
+		}),
+	)
+}
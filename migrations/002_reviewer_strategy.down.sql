@@ -0,0 +1,5 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS check_review_weight;
+ALTER TABLE users DROP COLUMN IF EXISTS review_weight;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS check_reviewer_strategy;
+ALTER TABLE teams DROP COLUMN IF EXISTS reviewer_strategy;
@@ -0,0 +1,6 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS reviewer_strategy VARCHAR(32) NOT NULL DEFAULT 'random';
+ALTER TABLE teams ADD CONSTRAINT check_reviewer_strategy
+    CHECK (reviewer_strategy IN ('random', 'round_robin', 'least_loaded', 'weighted'));
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS review_weight INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE users ADD CONSTRAINT check_review_weight CHECK (review_weight >= 0);
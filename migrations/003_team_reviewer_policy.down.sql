@@ -0,0 +1,3 @@
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS check_reviewers_bounds;
+ALTER TABLE teams DROP COLUMN IF EXISTS max_reviewers;
+ALTER TABLE teams DROP COLUMN IF EXISTS min_reviewers;
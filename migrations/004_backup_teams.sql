@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS team_backups (
+    team_name VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    backup_team_name VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    priority INTEGER NOT NULL,
+    PRIMARY KEY (team_name, backup_team_name),
+    CONSTRAINT check_backup_not_self CHECK (team_name <> backup_team_name)
+);
+
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS fallback_team VARCHAR(255);
@@ -0,0 +1,6 @@
+-- Fails while DRAFT or CLOSED PRs exist, since the old schema cannot hold them.
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS closed_at;
+
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS check_status;
+ALTER TABLE pull_requests ADD CONSTRAINT check_status
+    CHECK (status IN ('OPEN', 'MERGED'));
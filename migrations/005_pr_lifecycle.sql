@@ -0,0 +1,5 @@
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS check_status;
+ALTER TABLE pull_requests ADD CONSTRAINT check_status
+    CHECK (status IN ('DRAFT', 'OPEN', 'CLOSED', 'MERGED'));
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP;
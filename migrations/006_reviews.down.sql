@@ -0,0 +1,4 @@
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS check_required_approvals;
+ALTER TABLE teams DROP COLUMN IF EXISTS required_approvals;
+
+DROP TABLE IF EXISTS pr_reviews;
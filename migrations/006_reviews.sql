@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS pr_reviews (
+    review_id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    reviewer_id VARCHAR(255) NOT NULL REFERENCES users(user_id),
+    decision VARCHAR(32) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    submitted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT check_decision CHECK (decision IN ('APPROVED', 'CHANGES_REQUESTED', 'COMMENTED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_reviews_pr ON pr_reviews(pull_request_id, submitted_at);
+
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS required_approvals INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE teams ADD CONSTRAINT check_required_approvals CHECK (required_approvals >= 0);
@@ -0,0 +1,5 @@
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS force_merge_reason;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS force_merged;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS require_all_reviewers;
+ALTER TABLE teams DROP COLUMN IF EXISTS block_on_changes_requested;
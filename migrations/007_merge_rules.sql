@@ -0,0 +1,5 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS block_on_changes_requested BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS require_all_reviewers BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS force_merged BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS force_merge_reason TEXT;
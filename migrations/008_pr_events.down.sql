@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS pr_events_no_change ON pr_events;
+DROP FUNCTION IF EXISTS pr_events_append_only();
+DROP TABLE IF EXISTS pr_events;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS pr_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255),
+    event_type VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255),
+    from_status VARCHAR(255),
+    to_status VARCHAR(255),
+    actor VARCHAR(255),
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_events_pr ON pr_events(pull_request_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_pr_events_user ON pr_events(user_id, event_id);
+
+CREATE OR REPLACE FUNCTION pr_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'pr_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS pr_events_no_change ON pr_events;
+CREATE TRIGGER pr_events_no_change
+    BEFORE UPDATE OR DELETE ON pr_events
+    FOR EACH ROW EXECUTE FUNCTION pr_events_append_only();
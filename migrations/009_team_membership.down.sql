@@ -0,0 +1,7 @@
+-- Fails while users without a team exist.
+DROP INDEX IF EXISTS idx_pull_requests_author;
+
+ALTER TABLE pr_events DROP COLUMN IF EXISTS to_team;
+ALTER TABLE pr_events DROP COLUMN IF EXISTS from_team;
+
+ALTER TABLE users ALTER COLUMN team_name SET NOT NULL;
@@ -0,0 +1,7 @@
+-- Users removed from a team keep their history but no longer belong to one.
+ALTER TABLE users ALTER COLUMN team_name DROP NOT NULL;
+
+ALTER TABLE pr_events ADD COLUMN IF NOT EXISTS from_team VARCHAR(255);
+ALTER TABLE pr_events ADD COLUMN IF NOT EXISTS to_team VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_pull_requests_author ON pull_requests(author_id, status);
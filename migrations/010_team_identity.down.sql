@@ -0,0 +1,11 @@
+ALTER TABLE team_backups DROP CONSTRAINT IF EXISTS team_backups_backup_team_name_fkey;
+ALTER TABLE team_backups ADD CONSTRAINT team_backups_backup_team_name_fkey
+    FOREIGN KEY (backup_team_name) REFERENCES teams(team_name) ON DELETE CASCADE;
+
+ALTER TABLE team_backups DROP CONSTRAINT IF EXISTS team_backups_team_name_fkey;
+ALTER TABLE team_backups ADD CONSTRAINT team_backups_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_name_fkey;
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE;
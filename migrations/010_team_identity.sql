@@ -0,0 +1,18 @@
+-- References to a team follow its name on rename and deleting a team no longer
+-- cascades onto its users. The name stays the key rather than moving to a
+-- surrogate id: every reference is a foreign key to teams(team_name), so
+-- ON UPDATE CASCADE rewrites them all in the rename's transaction, and history
+-- in pr_events records names as they were at the time, with a TEAM_RENAMED event
+-- linking the old name to the new one.
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_name_fkey;
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+ALTER TABLE team_backups DROP CONSTRAINT IF EXISTS team_backups_team_name_fkey;
+ALTER TABLE team_backups ADD CONSTRAINT team_backups_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE team_backups DROP CONSTRAINT IF EXISTS team_backups_backup_team_name_fkey;
+ALTER TABLE team_backups ADD CONSTRAINT team_backups_backup_team_name_fkey
+    FOREIGN KEY (backup_team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
@@ -0,0 +1,18 @@
+-- API tokens. Only the SHA-256 of a token is stored; the token itself is
+-- shown once, when it is created. Team leads are scoped to a team and members
+-- to a user; tokens follow a team rename and go away with the team.
+CREATE TABLE IF NOT EXISTS api_tokens (
+    token_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(32) NOT NULL,
+    team_name VARCHAR(255) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_id VARCHAR(255) REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP,
+    CONSTRAINT check_token_role CHECK (role IN ('admin', 'team_lead', 'member', 'bot')),
+    CONSTRAINT check_token_scope CHECK (
+        (role = 'team_lead') = (team_name IS NOT NULL)
+        AND (role = 'member') = (user_id IS NOT NULL)
+    )
+);
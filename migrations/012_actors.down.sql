@@ -0,0 +1,4 @@
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS assigned_by;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS merged_by;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS created_by;
+ALTER TABLE teams DROP COLUMN IF EXISTS created_by;
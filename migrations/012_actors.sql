@@ -0,0 +1,6 @@
+-- Who made each change: set from the API token or the trusted actor header,
+-- NULL when unknown. The actor of every other change is in pr_events.actor.
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS created_by VARCHAR(255);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS created_by VARCHAR(255);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS merged_by VARCHAR(255);
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS assigned_by VARCHAR(255);
@@ -0,0 +1,7 @@
+-- Token buckets shared by the replicas when rate_limit.backend is postgres.
+-- Losing them in a crash only resets the quotas, so the table skips the WAL.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    bucket_key VARCHAR(512) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
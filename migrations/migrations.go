@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL schema migrations so the server binary
+// can apply them without the source tree. Each version is NNN_name.sql with
+// an optional NNN_name.down.sql that reverts it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var Files embed.FS
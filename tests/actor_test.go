@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+)
+
+func actorRequest(handler http.Handler, path, actor, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Actor", actor)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestActorFromTrustedHeaderIsStored(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	ctx := context.Background()
+	tokens := services.NewTokenService(env.Store, discardLogger)
+	authenticator := handlers.NewAuthenticator(tokens, false, "X-Actor", discardLogger)
+
+	addTeam := authenticator.Require(auth.PermAdmin, env.TeamHandler.AddTeam)
+	teamBody := `{"team_name": "backend", "members": [
+		{"user_id": "a1", "username": "A1", "is_active": true},
+		{"user_id": "a2", "username": "A2", "is_active": true},
+		{"user_id": "a3", "username": "A3", "is_active": true},
+		{"user_id": "a4", "username": "A4", "is_active": true}
+	]}`
+
+	if w := actorRequest(addTeam, "/team/add", "alice", teamBody); w.Code != http.StatusCreated {
+		t.Fatalf("failed to create team: %d - %s", w.Code, w.Body.String())
+	}
+
+	createPR := authenticator.Require(auth.PermPRWrite, env.PRHandler.CreatePR)
+	prBody := `{"pull_request_id": "pr-9500", "pull_request_name": "Audit", "author_id": "a1"}`
+
+	if w := actorRequest(createPR, "/pullRequest/create", "ci-bot", prBody); w.Code != http.StatusCreated {
+		t.Fatalf("failed to create PR: %d - %s", w.Code, w.Body.String())
+	}
+
+	pr, err := env.Store.GetPR(ctx, "pr-9500")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	reassign := authenticator.Require(auth.PermPRWrite, env.PRHandler.ReassignReviewer)
+	reassignBody := `{"pull_request_id": "pr-9500", "old_user_id": "` + pr.AssignedReviewers[0] + `"}`
+
+	if w := actorRequest(reassign, "/pullRequest/reassign", "bob", reassignBody); w.Code != http.StatusOK {
+		t.Fatalf("failed to reassign: %d - %s", w.Code, w.Body.String())
+	}
+
+	merge := authenticator.Require(auth.PermPRMerge, env.PRHandler.MergePR)
+
+	if w := actorRequest(merge, "/pullRequest/merge", "carol", `{"pull_request_id": "pr-9500"}`); w.Code != http.StatusOK {
+		t.Fatalf("failed to merge: %d - %s", w.Code, w.Body.String())
+	}
+
+	setActive := authenticator.Require(auth.PermTeamManage, env.UserHandler.SetUserActive)
+
+	if w := actorRequest(setActive, "/users/setIsActive", "dave", `{"user_id": "a4", "is_active": false}`); w.Code != http.StatusOK {
+		t.Fatalf("failed to deactivate: %d - %s", w.Code, w.Body.String())
+	}
+
+	team, err := env.Store.GetTeam(ctx, "backend")
+	if err != nil || team.CreatedBy != "alice" {
+		t.Errorf("expected the team to be created by alice, got %+v, %v", team, err)
+	}
+
+	pr, err = env.Store.GetPR(ctx, "pr-9500")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	if pr.CreatedBy != "ci-bot" || pr.MergedBy != "carol" {
+		t.Errorf("expected created_by ci-bot and merged_by carol, got %q and %q", pr.CreatedBy, pr.MergedBy)
+	}
+
+	replacement := pr.AssignedReviewers[len(pr.AssignedReviewers)-1]
+	if pr.AssignedBy[replacement] != "bob" || pr.AssignedBy[pr.AssignedReviewers[0]] != "ci-bot" {
+		t.Errorf("expected reviewers assigned by ci-bot and bob, got %v", pr.AssignedBy)
+	}
+
+	events, err := env.Store.GetPREvents(ctx, "pr-9500")
+	if err != nil {
+		t.Fatalf("failed to get events: %v", err)
+	}
+
+	for _, event := range events {
+		if event.Actor == "" {
+			t.Errorf("expected every event to carry an actor, got %+v", event)
+		}
+
+		if event.EventType == models.EventStatusChanged && event.ToStatus == models.PRStatusMerged && event.Actor != "carol" {
+			t.Errorf("expected the merge to be logged as carol's, got %q", event.Actor)
+		}
+	}
+}
+
+func TestActorFromTokenOverridesHeader(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	tokens := services.NewTokenService(env.Store, discardLogger)
+	authenticator := handlers.NewAuthenticator(tokens, true, "X-Actor", discardLogger)
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 3)
+	member := issueToken(t, tokens, auth.RoleMember, "", "u30")
+
+	createPR := authenticator.Require(auth.PermPRWrite, env.PRHandler.CreatePR)
+
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create",
+		strings.NewReader(`{"pull_request_id": "pr-9600", "pull_request_name": "Spoof", "author_id": "u30"}`))
+	req.Header.Set("Authorization", "Bearer "+member)
+	req.Header.Set("X-Actor", "someone-else")
+
+	w := httptest.NewRecorder()
+	createPR.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create PR: %d - %s", w.Code, w.Body.String())
+	}
+
+	pr, err := env.Store.GetPR(context.Background(), "pr-9600")
+	if err != nil || pr.CreatedBy != "u30" {
+		t.Errorf("expected the token's user as actor, got %+v, %v", pr, err)
+	}
+}
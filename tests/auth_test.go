@@ -0,0 +1,269 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+)
+
+func issueToken(t *testing.T, tokens *services.TokenService, role, teamName, userID string) string {
+	t.Helper()
+
+	token, err := tokens.CreateToken(context.Background(), &models.APIToken{
+		Name:     role + " token",
+		Role:     role,
+		TeamName: teamName,
+		UserID:   userID,
+	})
+
+	if err != nil {
+		t.Fatalf("failed to create %s token: %v", role, err)
+	}
+
+	return token.Token
+}
+
+func authorizedRequest(handler http.Handler, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestTokenAuthentication(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	tokens := services.NewTokenService(env.Store, discardLogger)
+	authenticator := handlers.NewAuthenticator(tokens, true, "", discardLogger)
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 3)
+
+	createPR := authenticator.Require(auth.PermPRWrite, env.PRHandler.CreatePR)
+	body := `{"pull_request_id": "pr-9000", "pull_request_name": "Auth", "author_id": "u30"}`
+
+	w := authorizedRequest(createPR, "/pullRequest/create", "", body)
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("expected 401 with a challenge without a token, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := authorizedRequest(createPR, "/pullRequest/create", "prs_not-a-token", body); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an unknown token, got %d", w.Code)
+	}
+
+	bot := issueToken(t, tokens, auth.RoleBot, "", "")
+	if w := authorizedRequest(createPR, "/pullRequest/create", bot, body); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a read-only bot, got %d: %s", w.Code, w.Body.String())
+	}
+
+	stored, err := env.Store.ListTokens(context.Background())
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("expected one stored token, got %v, %v", stored, err)
+	}
+
+	if _, err := env.Store.GetTokenByHash(context.Background(), bot); err == nil {
+		t.Error("expected tokens to be stored hashed, not as is")
+	}
+
+	admin := issueToken(t, tokens, auth.RoleAdmin, "", "")
+	if w := authorizedRequest(createPR, "/pullRequest/create", admin, body); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for an admin, got %d: %s", w.Code, w.Body.String())
+	}
+
+	principal, err := tokens.Authenticate(context.Background(), admin)
+	if err != nil {
+		t.Fatalf("failed to authenticate admin: %v", err)
+	}
+
+	if _, err := tokens.RevokeToken(context.Background(), principal.TokenID); err != nil {
+		t.Fatalf("failed to revoke token: %v", err)
+	}
+
+	if w := authorizedRequest(createPR, "/pullRequest/create", admin, body); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a revoked token, got %d", w.Code)
+	}
+
+	if _, err := tokens.CreateToken(context.Background(), &models.APIToken{Name: "lead", Role: auth.RoleTeamLead}); err == nil {
+		t.Error("expected a team_lead token without a team to be rejected")
+	}
+}
+
+func TestAuthorizationIsScopedToTeamAndPR(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	tokens := services.NewTokenService(env.Store, discardLogger)
+	authenticator := handlers.NewAuthenticator(tokens, true, "", discardLogger)
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+	addTestTeam(t, env, `{
+		"team_name": "frontend",
+		"members": [{"user_id": "f1", "username": "Front1", "is_active": true}]
+	}`)
+
+	setActive := authenticator.Require(auth.PermTeamManage, env.UserHandler.SetUserActive)
+	deactivate := `{"user_id": "u33", "is_active": false}`
+
+	frontendLead := issueToken(t, tokens, auth.RoleTeamLead, "frontend", "")
+	if w := authorizedRequest(setActive, "/users/setIsActive", frontendLead, deactivate); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another team's lead, got %d: %s", w.Code, w.Body.String())
+	}
+
+	backendLead := issueToken(t, tokens, auth.RoleTeamLead, "backend", "")
+	if w := authorizedRequest(setActive, "/users/setIsActive", backendLead, deactivate); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the team's lead, got %d: %s", w.Code, w.Body.String())
+	}
+
+	member := issueToken(t, tokens, auth.RoleMember, "", "u30")
+	if w := authorizedRequest(setActive, "/users/setIsActive", member, deactivate); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a member, got %d", w.Code)
+	}
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-9100", "Scoped", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("failed to create PR: %d - %s", w.Code, w.Body.String())
+	}
+
+	merge := authenticator.Require(auth.PermPRMerge, env.PRHandler.MergePR)
+
+	outsider := issueToken(t, tokens, auth.RoleMember, "", "f1")
+	if w := authorizedRequest(merge, "/pullRequest/merge", outsider, `{"pull_request_id": "pr-9100"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a member unrelated to the PR, got %d: %s", w.Code, w.Body.String())
+	}
+
+	forced := `{"pull_request_id": "pr-9100", "force": true, "reason": "hotfix"}`
+	if w := authorizedRequest(merge, "/pullRequest/merge", backendLead, forced); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a force merge by a team lead, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := authorizedRequest(merge, "/pullRequest/merge", member, `{"pull_request_id": "pr-9100"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected the author to merge their PR, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestReviewsAreSubmittedAsTheCaller(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	tokens := services.NewTokenService(env.Store, discardLogger)
+	authenticator := handlers.NewAuthenticator(tokens, true, "", discardLogger)
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+	addTestTeam(t, env, `{
+		"team_name": "frontend",
+		"members": [{"user_id": "f1", "username": "Front1", "is_active": true}]
+	}`)
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-9200", "Reviewed", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("failed to create PR: %d - %s", w.Code, w.Body.String())
+	}
+
+	pr, err := env.Store.GetPR(context.Background(), "pr-9200")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	review := authenticator.Require(auth.PermPRWrite, env.PRHandler.SubmitReview)
+	approve := func(reviewerID string) string {
+		return `{"pull_request_id": "pr-9200", "reviewer_id": "` + reviewerID + `", "decision": "APPROVED"}`
+	}
+
+	author := issueToken(t, tokens, auth.RoleMember, "", "u30")
+	if w := authorizedRequest(review, "/pullRequest/review", author, approve(pr.AssignedReviewers[0])); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a member reviewing as someone else, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reviewer := issueToken(t, tokens, auth.RoleMember, "", pr.AssignedReviewers[0])
+	if w := authorizedRequest(review, "/pullRequest/review", reviewer, approve(pr.AssignedReviewers[0])); w.Code != http.StatusOK {
+		t.Fatalf("expected the reviewer to review, got %d: %s", w.Code, w.Body.String())
+	}
+
+	frontendLead := issueToken(t, tokens, auth.RoleTeamLead, "frontend", "")
+	if w := authorizedRequest(review, "/pullRequest/review", frontendLead, approve(pr.AssignedReviewers[1])); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another team's lead, got %d: %s", w.Code, w.Body.String())
+	}
+
+	backendLead := issueToken(t, tokens, auth.RoleTeamLead, "backend", "")
+	if w := authorizedRequest(review, "/pullRequest/review", backendLead, approve(pr.AssignedReviewers[1])); w.Code != http.StatusOK {
+		t.Errorf("expected the team's lead to review for its member, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPRActionsAreScopedToAuthorAndReviewers(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	tokens := services.NewTokenService(env.Store, discardLogger)
+	authenticator := handlers.NewAuthenticator(tokens, true, "", discardLogger)
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 5)
+	addTestTeam(t, env, `{
+		"team_name": "frontend",
+		"members": [{"user_id": "f1", "username": "Front1", "is_active": true}]
+	}`)
+
+	createPR := authenticator.Require(auth.PermPRWrite, env.PRHandler.CreatePR)
+	body := `{"pull_request_id": "pr-9300", "pull_request_name": "Scoped", "author_id": "u30"}`
+
+	outsider := issueToken(t, tokens, auth.RoleMember, "", "f1")
+	if w := authorizedRequest(createPR, "/pullRequest/create", outsider, body); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a PR created in someone else's name, got %d: %s", w.Code, w.Body.String())
+	}
+
+	author := issueToken(t, tokens, auth.RoleMember, "", "u30")
+	if w := authorizedRequest(createPR, "/pullRequest/create", author, body); w.Code != http.StatusCreated {
+		t.Fatalf("expected the author to create their PR, got %d: %s", w.Code, w.Body.String())
+	}
+
+	pr, err := env.Store.GetPR(context.Background(), "pr-9300")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	reassign := authenticator.Require(auth.PermPRWrite, env.PRHandler.ReassignReviewer)
+	reassignBody := `{"pull_request_id": "pr-9300", "old_user_id": "` + pr.AssignedReviewers[0] + `"}`
+
+	frontendLead := issueToken(t, tokens, auth.RoleTeamLead, "frontend", "")
+	if w := authorizedRequest(reassign, "/pullRequest/reassign", frontendLead, reassignBody); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another team's lead, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reviewer := issueToken(t, tokens, auth.RoleMember, "", pr.AssignedReviewers[0])
+	if w := authorizedRequest(reassign, "/pullRequest/reassign", reviewer, reassignBody); w.Code != http.StatusOK {
+		t.Fatalf("expected an assigned reviewer to reassign, got %d: %s", w.Code, w.Body.String())
+	}
+
+	closePR := authenticator.Require(auth.PermPRWrite, env.PRHandler.ClosePR)
+	reopenPR := authenticator.Require(auth.PermPRWrite, env.PRHandler.ReopenPR)
+	target := `{"pull_request_id": "pr-9300"}`
+
+	if w := authorizedRequest(closePR, "/pullRequest/close", outsider, target); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for closing someone else's PR, got %d: %s", w.Code, w.Body.String())
+	}
+
+	backendLead := issueToken(t, tokens, auth.RoleTeamLead, "backend", "")
+	if w := authorizedRequest(closePR, "/pullRequest/close", backendLead, target); w.Code != http.StatusOK {
+		t.Fatalf("expected the team's lead to close the PR, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := authorizedRequest(reopenPR, "/pullRequest/reopen", outsider, target); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for reopening someone else's PR, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := authorizedRequest(reopenPR, "/pullRequest/reopen", author, target); w.Code != http.StatusOK {
+		t.Errorf("expected the author to reopen the PR, got %d: %s", w.Code, w.Body.String())
+	}
+}
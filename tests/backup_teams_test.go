@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func addTestTeam(t *testing.T, env *TestEnvironment, payload string) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/team/add", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.TeamHandler.AddTeam(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create team: %d - %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreatePRFallsBackToBackupTeam(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	addTestTeam(t, env, `{
+		"team_name": "infra",
+		"members": [
+			{"user_id": "u40", "username": "User40", "is_active": true},
+			{"user_id": "u41", "username": "User41", "is_active": true}
+		]
+	}`)
+	addTestTeam(t, env, `{
+		"team_name": "backend",
+		"backup_teams": ["infra"],
+		"members": [
+			{"user_id": "u30", "username": "User30", "is_active": true},
+			{"user_id": "u31", "username": "User31", "is_active": true},
+			{"user_id": "u32", "username": "User32", "is_active": false}
+		]
+	}`)
+
+	w := CreateTestPR(t, env.PRHandler, "pr-8000", "Fallback", "u30")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	pr := response["pr"].(map[string]interface{})
+	reviewers := pr["assigned_reviewers"].([]interface{})
+
+	if len(reviewers) != 2 {
+		t.Fatalf("expected 2 reviewers, got %v", reviewers)
+	}
+
+	fallback, ok := pr["fallback_reviewers"].(map[string]interface{})
+
+	if !ok || len(fallback) != 1 {
+		t.Fatalf("expected exactly one fallback reviewer, got %v", pr["fallback_reviewers"])
+	}
+
+	for _, reviewer := range reviewers {
+		if reviewer == "u31" {
+			if _, borrowed := fallback["u31"]; borrowed {
+				t.Errorf("own team member u31 should not be marked as fallback")
+			}
+		} else if fallback[reviewer.(string)] != "infra" {
+			t.Errorf("expected %v to come from infra, got %v", reviewer, fallback)
+		}
+	}
+}
+
+func TestReassignFallsBackToBackupTeam(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	addTestTeam(t, env, `{
+		"team_name": "infra",
+		"members": [{"user_id": "u40", "username": "User40", "is_active": true}]
+	}`)
+	addTestTeam(t, env, `{
+		"team_name": "backend",
+		"backup_teams": ["infra"],
+		"max_reviewers": 1,
+		"members": [
+			{"user_id": "u30", "username": "User30", "is_active": true},
+			{"user_id": "u31", "username": "User31", "is_active": true}
+		]
+	}`)
+
+	w := CreateTestPR(t, env.PRHandler, "pr-8001", "Fallback", "u30")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	payload := `{"pull_request_id": "pr-8001", "old_user_id": "u31"}`
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/reassign", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w2 := httptest.NewRecorder()
+
+	env.PRHandler.ReassignReviewer(w2, req)
+
+	if w2.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w2.Code, w2.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w2.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response["replaced_by"] != "u40" {
+		t.Errorf("expected u40 from backup team, got %v", response["replaced_by"])
+	}
+}
+
+func TestBulkDeactivationReplacesFromTheAuthorsTeam(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	addTestTeam(t, env, `{
+		"team_name": "infra",
+		"members": [{"user_id": "u40", "username": "User40", "is_active": true}]
+	}`)
+	addTestTeam(t, env, `{
+		"team_name": "backend",
+		"backup_teams": ["infra"],
+		"max_reviewers": 1,
+		"members": [
+			{"user_id": "u30", "username": "User30", "is_active": true},
+			{"user_id": "u31", "username": "User31", "is_active": false}
+		]
+	}`)
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-8002", "Fallback", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/users/setIsActive", bytes.NewBufferString(`{"user_id": "u31", "is_active": true}`))
+	w := httptest.NewRecorder()
+	env.UserHandler.SetUserActive(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to activate u31: %d - %s", w.Code, w.Body.String())
+	}
+
+	// u40 was borrowed from infra, which has nobody left; like a manual
+	// reassignment, the review goes back to the author's team.
+	req = httptest.NewRequest(http.MethodPost, "/team/deactivateUsers", bytes.NewBufferString(`{"team_name": "infra", "user_ids": ["u40"]}`))
+	w = httptest.NewRecorder()
+	env.TeamHandler.DeactivateUsers(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	pr, err := env.Store.GetPR(context.Background(), "pr-8002")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	if len(pr.AssignedReviewers) != 1 || pr.AssignedReviewers[0] != "u31" || pr.FallbackReviewers["u31"] != "" {
+		t.Errorf("expected u31 from the author's team to take over, got %v (fallback %v)", pr.AssignedReviewers, pr.FallbackReviewers)
+	}
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/config"
+)
+
+func loadTestConfig(t *testing.T, args []string, env map[string]string) (*config.Config, error) {
+	t.Helper()
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	return config.Load(fs, args, func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(`
+http:
+  port: 9000
+  write_timeout: 1m
+database:
+  host: file-host
+  user: file-user
+reviewers:
+  strategy: least_loaded
+`), 0o600)
+
+	if err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	cfg, err := loadTestConfig(t,
+		[]string{"-config", path, "-db-host", "flag-host", "-migrate=false", "migrate", "status"},
+		map[string]string{"DB_HOST": "env-host", "DB_USER": "env-user", "PORT": "9100"},
+	)
+
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if cfg.Database.Host != "flag-host" {
+		t.Errorf("expected flag to override env and file, got %s", cfg.Database.Host)
+	}
+
+	if cfg.Database.User != "env-user" || cfg.HTTP.Port != 9100 {
+		t.Errorf("expected env to override file, got user %s, port %d", cfg.Database.User, cfg.HTTP.Port)
+	}
+
+	if cfg.HTTP.WriteTimeout != time.Minute || cfg.Reviewers.Strategy != "least_loaded" {
+		t.Errorf("expected file values to be used, got %v, %s", cfg.HTTP.WriteTimeout, cfg.Reviewers.Strategy)
+	}
+
+	if cfg.Database.MigrateOnStart {
+		t.Error("expected -migrate=false to turn off migrations at startup")
+	}
+
+	if cfg.Database.MaxOpenConns != 25 || cfg.HTTP.ReadHeaderTimeout != 5*time.Second {
+		t.Errorf("expected defaults for unset values, got %+v", cfg)
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	_, err := loadTestConfig(t,
+		[]string{"-port", "0", "-reviewer-strategy", "bogus", "-db-sslmode", "verify-full", "-db-sslcert", "client.crt"},
+		nil,
+	)
+
+	if err == nil {
+		t.Fatal("expected invalid configuration to be rejected")
+	}
+
+	for _, key := range []string{"http.port", "reviewers.strategy", "database.sslcert"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected %s to be reported, got: %v", key, err)
+		}
+	}
+
+	if _, err := loadTestConfig(t, nil, map[string]string{"DB_MAX_OPEN_CONNS": "many"}); err == nil {
+		t.Error("expected a malformed environment value to be rejected")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("http:\n  prot: 9000\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	if _, err := loadTestConfig(t, []string{"-config", path}, nil); err == nil {
+		t.Error("expected an unknown key in the config file to be rejected")
+	}
+}
+
+func TestConfigPrintRedactsSecrets(t *testing.T) {
+	cfg, err := loadTestConfig(t, nil, map[string]string{
+		"DB_PASSWORD": "hunter2",
+		"DB_DSN":      "host=db user=app password='s3cr et' dbname=app",
+	})
+
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	out, err := cfg.Redacted().YAML()
+	if err != nil {
+		t.Fatalf("failed to render config: %v", err)
+	}
+
+	for _, secret := range []string{"hunter2", "s3cr"} {
+		if strings.Contains(string(out), secret) {
+			t.Errorf("expected %q to be redacted:\n%s", secret, out)
+		}
+	}
+
+	if cfg.Database.Password != "hunter2" {
+		t.Error("expected Redacted to leave the original configuration untouched")
+	}
+
+	if cfg.Database.ConnString() != "host=db user=app password='s3cr et' dbname=app" {
+		t.Errorf("expected the DSN to be used as is, got %s", cfg.Database.ConnString())
+	}
+}
+
+func TestConfigAllowsAuthWithMemoryStorage(t *testing.T) {
+	cfg, err := loadTestConfig(t, []string{"-storage", "memory"}, nil)
+
+	if err != nil {
+		t.Fatalf("expected memory storage to work with auth on, got: %v", err)
+	}
+
+	if !cfg.Auth.Enabled {
+		t.Error("expected auth to stay on by default for memory storage")
+	}
+}
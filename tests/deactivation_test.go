@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+)
+
+func deactivateTestUser(t *testing.T, env *TestEnvironment, userID string) map[string]interface{} {
+	t.Helper()
+
+	payload, _ := json.Marshal(map[string]interface{}{"user_id": userID, "is_active": false})
+	req := httptest.NewRequest(http.MethodPost, "/users/setIsActive", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.UserHandler.SetUserActive(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	return response
+}
+
+func TestDeactivationReassignsOpenReviews(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+
+	w := CreateTestPR(t, env.PRHandler, "pr-9800", "Stalled", "u30")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reviewers := decodePR(t, w)["assigned_reviewers"].([]interface{})
+	first, second := reviewers[0].(string), reviewers[1].(string)
+
+	response := deactivateTestUser(t, env, first)
+
+	if user := response["user"].(map[string]interface{}); user["is_active"] != false {
+		t.Errorf("expected user to be inactive, got %v", user)
+	}
+
+	reassigned := response["review_rebalance"].(map[string]interface{})["reassigned"].([]interface{})
+
+	if len(reassigned) != 1 {
+		t.Fatalf("expected 1 reassignment, got %v", response)
+	}
+
+	replacement := reassigned[0].(map[string]interface{})["new_reviewer_id"].(string)
+
+	pr, err := env.Store.GetPR(context.Background(), "pr-9800")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	for _, reviewer := range pr.AssignedReviewers {
+		if reviewer == first {
+			t.Errorf("deactivated reviewer %s is still assigned", first)
+		}
+	}
+
+	response = deactivateTestUser(t, env, replacement)
+
+	if noCandidate := response["review_rebalance"].(map[string]interface{})["no_candidate"].([]interface{}); len(noCandidate) != 1 {
+		t.Fatalf("expected 1 PR without candidate, got %v", response)
+	}
+
+	pr, err = env.Store.GetPR(context.Background(), "pr-9800")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	if len(pr.AssignedReviewers) != 1 || pr.AssignedReviewers[0] != second {
+		t.Errorf("expected only %s to remain assigned, got %v", second, pr.AssignedReviewers)
+	}
+}
+
+func TestBulkTeamDeactivation(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 6)
+
+	prIDs := []string{"pr-9900", "pr-9901", "pr-9902"}
+	for _, prID := range prIDs {
+		if w := CreateTestPR(t, env.PRHandler, prID, "Reorg", "u30"); w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	payload := `{"team_name": "backend", "user_ids": ["u31", "u32", "u33"]}`
+	req := httptest.NewRequest(http.MethodPost, "/team/deactivateUsers", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.TeamHandler.DeactivateUsers(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if deactivated := response["deactivated"].([]interface{}); len(deactivated) != 3 {
+		t.Errorf("expected 3 deactivated users, got %v", deactivated)
+	}
+
+	inactive := map[string]bool{"u31": true, "u32": true, "u33": true}
+
+	for _, prID := range prIDs {
+		pr, err := env.Store.GetPR(context.Background(), prID)
+		if err != nil {
+			t.Fatalf("failed to get PR: %v", err)
+		}
+
+		if len(pr.AssignedReviewers) != 2 {
+			t.Errorf("%s: expected 2 reviewers after rebalancing, got %v", prID, pr.AssignedReviewers)
+		}
+
+		for _, reviewer := range pr.AssignedReviewers {
+			if inactive[reviewer] {
+				t.Errorf("%s: inactive reviewer %s is still assigned", prID, reviewer)
+			}
+		}
+	}
+}
+
+func TestBulkTeamDeactivationRejectsOutsiders(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 3)
+
+	payload := `{"team_name": "backend", "user_ids": ["u31", "u99"]}`
+	req := httptest.NewRequest(http.MethodPost, "/team/deactivateUsers", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.TeamHandler.DeactivateUsers(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	user, err := env.Store.GetUser(context.Background(), "u31")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	if !user.IsActive {
+		t.Error("no user should be deactivated when the request is rejected")
+	}
+}
+
+func TestUserServiceDoesNotDeactivateWithoutReassigning(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 3)
+
+	users := services.NewUserService(env.Store)
+
+	if _, err := users.SetUserActive(context.Background(), "u31", false); !errors.Is(err, services.ErrBadRequest) {
+		t.Fatalf("expected deactivation to be rejected, got %v", err)
+	}
+
+	user, err := env.Store.GetUser(context.Background(), "u31")
+	if err != nil || !user.IsActive {
+		t.Errorf("expected the user to stay active, got %+v, %v", user, err)
+	}
+}
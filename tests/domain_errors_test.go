@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+)
+
+func TestDomainErrorsMatchTheirSentinel(t *testing.T) {
+	derived := services.ErrStatusConflict.WithMessage("reviews changed concurrently, retry")
+
+	if !errors.Is(derived, services.ErrStatusConflict) {
+		t.Error("expected derived error to match its sentinel")
+	}
+
+	if errors.Is(derived, services.ErrNotAssigned) {
+		t.Error("expected derived error not to match an unrelated sentinel")
+	}
+
+	if errors.Is(services.ErrPRNotFound, services.ErrTeamNotFound) {
+		t.Error("sentinels sharing a code must still be distinct")
+	}
+
+	var domainErr *services.DomainError
+	if !errors.As(derived, &domainErr) || domainErr.Code != "STATUS_CONFLICT" || domainErr.Status != http.StatusConflict {
+		t.Errorf("expected STATUS_CONFLICT/409, got %+v", domainErr)
+	}
+}
+
+func TestErrorResponsesAreConsistent(t *testing.T) {
+	env := SetupMemoryEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 3)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		payload string
+		status  int
+		code    string
+	}{
+		{"empty PR id", env.PRHandler.CreatePR, `{"pull_request_name": "x", "author_id": "u30"}`, http.StatusBadRequest, "BAD_REQUEST"},
+		{"unknown author", env.PRHandler.CreatePR, `{"pull_request_id": "pr-1", "pull_request_name": "x", "author_id": "u99"}`, http.StatusNotFound, "NOT_FOUND"},
+		{"reassign on unknown PR", env.PRHandler.ReassignReviewer, `{"pull_request_id": "pr-404", "old_user_id": "u31"}`, http.StatusNotFound, "NOT_FOUND"},
+		{"unknown user", env.UserHandler.SetUserActive, `{"user_id": "u99", "is_active": true}`, http.StatusNotFound, "USER_NOT_FOUND"},
+		{"duplicate team", env.TeamHandler.AddTeam, `{"team_name": "backend", "members": [{"user_id": "u1", "username": "A", "is_active": true}]}`, http.StatusBadRequest, "TEAM_EXISTS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+
+			var response map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			errorObj := response["error"].(map[string]interface{})
+
+			if errorObj["code"] != tt.code {
+				t.Errorf("expected error code %s, got %v", tt.code, errorObj["code"])
+			}
+
+			if errorObj["message"] == "" {
+				t.Error("expected a non-empty error message")
+			}
+		})
+	}
+}
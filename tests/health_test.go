@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+)
+
+func probe(t *testing.T, handler http.HandlerFunc, wantStatus int) map[string]interface{} {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != wantStatus {
+		t.Fatalf("expected %d, got %d: %s", wantStatus, w.Code, w.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	return response
+}
+
+func TestReadinessReportsEachCheck(t *testing.T) {
+	health := handlers.NewHealthHandler(time.Second, discardLogger)
+
+	var dbErr error
+	health.AddCheck("database", func(ctx context.Context) (interface{}, error) {
+		return map[string]int{"open_connections": 1}, dbErr
+	})
+	health.AddCheck("migrations", func(ctx context.Context) (interface{}, error) {
+		return nil, nil
+	})
+
+	response := probe(t, health.Readyz, http.StatusOK)
+
+	if response["status"] != "ok" {
+		t.Errorf("expected ok, got %v", response)
+	}
+
+	dbErr = errors.New("connection refused")
+	response = probe(t, health.Readyz, http.StatusServiceUnavailable)
+
+	checks := response["checks"].(map[string]interface{})
+	database := checks["database"].(map[string]interface{})
+
+	if database["status"] != "unavailable" || database["error"] != "connection refused" {
+		t.Errorf("expected the database check to fail, got %v", database)
+	}
+
+	if database["details"] == nil {
+		t.Error("expected details to be reported for a failing check")
+	}
+
+	if checks["migrations"].(map[string]interface{})["status"] != "ok" {
+		t.Errorf("expected the migrations check to pass, got %v", checks["migrations"])
+	}
+
+	probe(t, health.Livez, http.StatusOK)
+}
+
+func TestReadinessChecksHaveADeadline(t *testing.T) {
+	health := handlers.NewHealthHandler(10*time.Millisecond, discardLogger)
+
+	health.AddCheck("database", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	probe(t, health.Readyz, http.StatusServiceUnavailable)
+}
+
+func TestHealthReportsDraining(t *testing.T) {
+	health := handlers.NewHealthHandler(time.Second, discardLogger)
+
+	probe(t, health.Readyz, http.StatusOK)
+
+	health.SetDraining()
+
+	if response := probe(t, health.Readyz, http.StatusServiceUnavailable); response["status"] != "draining" {
+		t.Errorf("expected draining, got %v", response)
+	}
+
+	probe(t, health.Livez, http.StatusOK)
+}
@@ -0,0 +1,245 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+	"github.com/Jersonmade/pr-reviewer-service/internal/idempotency"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+)
+
+// idempotentHandler puts the idempotency middleware behind an authenticator
+// with auth disabled, so that keys are scoped to the X-Actor header.
+func idempotentHandler(mux *http.ServeMux) http.Handler {
+	tokens := services.NewTokenService(storage.NewMemoryStorage(), discardLogger)
+	authenticator := handlers.NewAuthenticator(tokens, false, "X-Actor", discardLogger)
+
+	return authenticator.Middleware(idempotency.Middleware(idempotency.NewMemoryStore(time.Hour), mux, mux, discardLogger))
+}
+
+func idempotentRequest(handler http.Handler, path, key, actor, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotency.Header, key)
+	if actor != "" {
+		req.Header.Set("X-Actor", actor)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestIdempotentRetriesAreReplayed(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 6)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pullRequest/create", env.PRHandler.CreatePR)
+	mux.HandleFunc("/pullRequest/reassign", env.PRHandler.ReassignReviewer)
+	handler := idempotentHandler(mux)
+
+	createBody := `{"pull_request_id": "pr-9700", "pull_request_name": "Retry", "author_id": "u30"}`
+
+	first := idempotentRequest(handler, "/pullRequest/create", "create-1", "ci", createBody)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("failed to create PR: %d - %s", first.Code, first.Body.String())
+	}
+
+	retry := idempotentRequest(handler, "/pullRequest/create", "create-1", "ci", createBody)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected the create to be replayed, got %d - %s", retry.Code, retry.Body.String())
+	}
+
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected the replay to be marked")
+	}
+
+	if w := idempotentRequest(handler, "/pullRequest/create", "create-2", "ci", createBody); w.Code != http.StatusConflict {
+		t.Errorf("expected a new key to run the create again and conflict, got %d", w.Code)
+	}
+
+	pr, err := env.Store.GetPR(context.Background(), "pr-9700")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	reassignBody := `{"pull_request_id": "pr-9700", "old_user_id": "` + pr.AssignedReviewers[0] + `"}`
+
+	first = idempotentRequest(handler, "/pullRequest/reassign", "reassign-1", "ci", reassignBody)
+	if first.Code != http.StatusOK {
+		t.Fatalf("failed to reassign: %d - %s", first.Code, first.Body.String())
+	}
+
+	retry = idempotentRequest(handler, "/pullRequest/reassign", "reassign-1", "ci", reassignBody)
+	if retry.Code != http.StatusOK || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected the reassignment to be replayed, got %d - %s", retry.Code, retry.Body.String())
+	}
+
+	events, err := env.Store.GetPREvents(context.Background(), "pr-9700")
+	if err != nil {
+		t.Fatalf("failed to get events: %v", err)
+	}
+
+	unassigned := 0
+	for _, event := range events {
+		if event.EventType == models.EventReviewerUnassigned {
+			unassigned++
+		}
+	}
+
+	if unassigned != 1 {
+		t.Errorf("expected one reviewer to be replaced, got %d", unassigned)
+	}
+}
+
+func TestIdempotencyKeyReusedForDifferentRequest(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/team/add", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/team/rename", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := idempotentHandler(mux)
+
+	if w := idempotentRequest(handler, "/team/add", "k1", "ci", `{"team_name": "a"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+
+	for _, tc := range []struct{ path, body string }{
+		{"/team/add", `{"team_name": "b"}`},
+		{"/team/rename", `{"team_name": "a"}`},
+	} {
+		w := idempotentRequest(handler, tc.path, "k1", "ci", tc.body)
+
+		var resp models.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if w.Code != http.StatusConflict || resp.Error.Code != "IDEMPOTENCY_KEY_REUSED" {
+			t.Errorf("%s: expected 409 IDEMPOTENCY_KEY_REUSED, got %d - %s", tc.path, w.Code, w.Body.String())
+		}
+	}
+
+	// Keys are scoped to the caller.
+	if w := idempotentRequest(handler, "/team/add", "k1", "other", `{"team_name": "b"}`); w.Code != http.StatusCreated {
+		t.Errorf("expected another caller's key to be separate, got %d", w.Code)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected the handlers to run twice, got %d", calls)
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pullRequest/merge", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := idempotentHandler(mux)
+
+	body := `{"pull_request_id": "pr-1"}`
+
+	if w := idempotentRequest(handler, "/pullRequest/merge", "m1", "ci", body); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+
+	if w := idempotentRequest(handler, "/pullRequest/merge", "m1", "ci", body); w.Code != http.StatusOK || calls != 2 {
+		t.Errorf("expected the retry to run again, got %d after %d calls", w.Code, calls)
+	}
+
+	if w := idempotentRequest(handler, "/pullRequest/merge", "m1", "ci", body); w.Code != http.StatusOK || calls != 2 {
+		t.Errorf("expected the successful response to be replayed, got %d after %d calls", w.Code, calls)
+	}
+}
+
+func TestIdempotencyNeedsAValidCaller(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 6)
+
+	tokens := services.NewTokenService(env.Store, discardLogger)
+	authenticator := handlers.NewAuthenticator(tokens, true, "", discardLogger)
+	admin := issueToken(t, tokens, auth.RoleAdmin, "", "")
+
+	mux := http.NewServeMux()
+	mux.Handle("/pullRequest/create", authenticator.Require(auth.PermPRWrite, env.PRHandler.CreatePR))
+	handler := authenticator.Middleware(idempotency.Middleware(idempotency.NewMemoryStore(time.Hour), mux, mux, discardLogger))
+
+	send := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/create",
+			strings.NewReader(`{"pull_request_id": "pr-9701", "pull_request_name": "Retry", "author_id": "u30"}`))
+		req.Header.Set(idempotency.Header, "k1")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		return w
+	}
+
+	if w := send(admin); w.Code != http.StatusCreated {
+		t.Fatalf("failed to create PR: %d - %s", w.Code, w.Body.String())
+	}
+
+	issued, err := tokens.ListTokens(context.Background())
+	if err != nil || len(issued) != 1 {
+		t.Fatalf("failed to list tokens: %v, %v", issued, err)
+	}
+
+	if _, err := tokens.RevokeToken(context.Background(), issued[0].TokenID); err != nil {
+		t.Fatalf("failed to revoke token: %v", err)
+	}
+
+	for _, token := range []string{admin, "invalid"} {
+		w := send(token)
+
+		if w.Code != http.StatusUnauthorized || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("expected the stored response not to be replayed, got %d - %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestIdempotencyIsSkippedWithoutACaller(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/team/add", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+	handler := idempotentHandler(mux)
+
+	for i := 0; i < 2; i++ {
+		if w := idempotentRequest(handler, "/team/add", "k1", "", `{"team_name": "a"}`); w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d", w.Code)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("expected anonymous requests not to share a key, got %d calls", calls)
+	}
+}
@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/auth"
+	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+	"github.com/Jersonmade/pr-reviewer-service/internal/limits"
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+)
+
+func limitedRequest(handler http.Handler, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestRateLimitPerRouteAndClient(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	tokens := services.NewTokenService(env.Store, discardLogger)
+	authenticator := handlers.NewAuthenticator(tokens, true, "", discardLogger)
+	bot := issueToken(t, tokens, auth.RoleBot, "", "")
+	other := issueToken(t, tokens, auth.RoleAdmin, "", "")
+
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	mux.HandleFunc("/pullRequest/create", ok)
+	mux.HandleFunc("/team/add", ok)
+	mux.HandleFunc("/livez", ok)
+
+	routes, err := limits.ParseRouteQuotas("/pullRequest/create=1:2")
+	if err != nil {
+		t.Fatalf("failed to parse quotas: %v", err)
+	}
+
+	limiter := limits.NewLimiter(limits.NewMemoryStore(), limits.Quota{PerMinute: 600, Burst: 100}, routes, "", discardLogger)
+	handler := authenticator.Middleware(limiter.Middleware(mux, mux, "/livez"))
+
+	for i := 0; i < 2; i++ {
+		if w := limitedRequest(handler, "/pullRequest/create", bot); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200 within the burst, got %d", i, w.Code)
+		}
+	}
+
+	w := limitedRequest(handler, "/pullRequest/create", bot)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 past the burst, got %d", w.Code)
+	}
+
+	if retry := w.Header().Get("Retry-After"); retry == "" || retry == "0" {
+		t.Errorf("expected a positive Retry-After, got %q", retry)
+	}
+
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error.Code != "RATE_LIMITED" {
+		t.Errorf("expected a RATE_LIMITED error body, got %s", w.Body.String())
+	}
+
+	if w := limitedRequest(handler, "/pullRequest/create", other); w.Code != http.StatusOK {
+		t.Errorf("expected another token to have its own bucket, got %d", w.Code)
+	}
+
+	if w := limitedRequest(handler, "/team/add", bot); w.Code != http.StatusOK {
+		t.Errorf("expected another route to have its own bucket, got %d", w.Code)
+	}
+
+	for i := 0; i < 5; i++ {
+		if w := limitedRequest(handler, "/livez", ""); w.Code != http.StatusOK {
+			t.Fatalf("expected exempt routes not to be limited, got %d", w.Code)
+		}
+	}
+}
+
+func TestRateLimitKeysInvalidTokensOnAddress(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	tokens := services.NewTokenService(env.Store, discardLogger)
+	authenticator := handlers.NewAuthenticator(tokens, true, "", discardLogger)
+
+	mux := http.NewServeMux()
+	mux.Handle("/pullRequest/create", authenticator.Require(auth.PermPRWrite, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	limiter := limits.NewLimiter(limits.NewMemoryStore(), limits.Quota{PerMinute: 1, Burst: 2}, nil, "", discardLogger)
+	handler := authenticator.Middleware(limiter.Middleware(mux, mux))
+
+	for i, token := range []string{"prs_made-up-1", "prs_made-up-2"} {
+		if w := limitedRequest(handler, "/pullRequest/create", token); w.Code != http.StatusUnauthorized {
+			t.Fatalf("request %d: expected 401 for an invalid token, got %d", i, w.Code)
+		}
+	}
+
+	if w := limitedRequest(handler, "/pullRequest/create", "prs_made-up-3"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected new invalid tokens to share the address's bucket, got %d", w.Code)
+	}
+}
+
+func TestRateLimitRefills(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/team/add", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	// 600 a minute is one every 100ms.
+	limiter := limits.NewLimiter(limits.NewMemoryStore(), limits.Quota{PerMinute: 600, Burst: 1}, nil, "", discardLogger)
+	handler := limiter.Middleware(mux, mux)
+
+	if w := limitedRequest(handler, "/team/add", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	if w := limitedRequest(handler, "/team/add", ""); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	if w := limitedRequest(handler, "/team/add", ""); w.Code != http.StatusOK {
+		t.Errorf("expected the bucket to refill, got %d", w.Code)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/team/add", env.TeamHandler.AddTeam)
+	handler := limits.MaxBody(64, mux, discardLogger)
+
+	body := `{"team_name": "backend", "members": [{"user_id": "u1", "username": "` + strings.Repeat("x", 100) + `", "is_active": true}]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/team/add", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for a declared oversized body, got %d", w.Code)
+	}
+
+	// Without Content-Length the limit is hit while decoding.
+	req = httptest.NewRequest(http.MethodPost, "/team/add", io.NopCloser(strings.NewReader(body)))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if w.Code != http.StatusRequestEntityTooLarge || resp.Error.Code != "PAYLOAD_TOO_LARGE" {
+		t.Errorf("expected 413 PAYLOAD_TOO_LARGE for a streamed oversized body, got %d - %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/team/add",
+		strings.NewReader(`{"team_name": "b", "members": []}`))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code == http.StatusRequestEntityTooLarge {
+		t.Errorf("expected a small body to pass, got %d", w.Code)
+	}
+}
+
+func TestParseRouteQuotas(t *testing.T) {
+	quotas, err := limits.ParseRouteQuotas(" /pullRequest/create=60:10, /pullRequest/merge=120 ")
+	if err != nil {
+		t.Fatalf("failed to parse quotas: %v", err)
+	}
+
+	if quotas["/pullRequest/create"] != (limits.Quota{PerMinute: 60, Burst: 10}) {
+		t.Errorf("unexpected create quota: %+v", quotas["/pullRequest/create"])
+	}
+
+	if quotas["/pullRequest/merge"] != (limits.Quota{PerMinute: 120, Burst: 120}) {
+		t.Errorf("expected the burst to default to the per-minute quota, got %+v", quotas["/pullRequest/merge"])
+	}
+
+	for _, bad := range []string{"pullRequest/create=60", "/team/add", "/team/add=0", "/team/add=10:x"} {
+		if _, err := limits.ParseRouteQuotas(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
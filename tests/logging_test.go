@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+	"github.com/Jersonmade/pr-reviewer-service/internal/logging"
+	"github.com/Jersonmade/pr-reviewer-service/internal/services"
+	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+)
+
+// logLines decodes the JSON log records written to buf.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("log line is not JSON: %s", scanner.Text())
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func findLogLine(lines []map[string]interface{}, msg string) map[string]interface{} {
+	for _, line := range lines {
+		if line["msg"] == msg {
+			return line
+		}
+	}
+
+	return nil
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := logging.New(&buf, "json", "info")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/teams/{name}", func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "handling", "request_id_seen", logging.RequestID(r.Context()))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	handler := logging.Middleware(logger, mux, mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/teams/backend", nil)
+	req.Header.Set(logging.RequestIDHeader, "client-id-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get(logging.RequestIDHeader); got != "client-id-1" {
+		t.Errorf("expected the client's request ID to be echoed, got %q", got)
+	}
+
+	lines := logLines(t, &buf)
+
+	handling := findLogLine(lines, "handling")
+	if handling == nil || handling["request_id"] != "client-id-1" || handling["route"] != "/teams/{name}" ||
+		handling["request_id_seen"] != "client-id-1" {
+		t.Errorf("expected the handler's log line to carry the request ID and route, got %v", handling)
+	}
+
+	served := findLogLine(lines, "request served")
+	if served == nil || served["request_id"] != "client-id-1" || served["status"] != float64(http.StatusNoContent) {
+		t.Errorf("expected an access log line, got %v", served)
+	}
+
+	for _, header := range []string{"", "has spaces", string(bytes.Repeat([]byte("x"), 200))} {
+		req := httptest.NewRequest(http.MethodGet, "/nowhere", nil)
+		req.Header.Set(logging.RequestIDHeader, header)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		got := w.Header().Get(logging.RequestIDHeader)
+		if got == "" || got == header {
+			t.Errorf("expected a fresh request ID instead of %q, got %q", header, got)
+		}
+	}
+}
+
+func TestServiceLogsCarryRequestContext(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := logging.New(&buf, "json", "debug")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	store := storage.NewMemoryStorage()
+	userService := services.NewUserService(store)
+	teamService := services.NewTeamService(store, services.DefaultOptions(), logger)
+	prService := services.NewPRService(store, userService, services.DefaultOptions(), logger)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/team/add", handlers.NewTeamHandler(teamService, prService, logger).AddTeam)
+	mux.HandleFunc("/pullRequest/create", handlers.NewPRHandler(prService, logger).CreatePR)
+
+	handler := logging.Middleware(logger, mux, mux)
+
+	CreateTestTeam(t, handlers.NewTeamHandler(teamService, prService, logger), "logging", 2)
+
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create",
+		bytes.NewBufferString(`{"pull_request_id": "pr-log", "pull_request_name": "Logs", "author_id": "u30"}`))
+	req.Header.Set(logging.RequestIDHeader, "req-42")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	lines := logLines(t, &buf)
+
+	for _, msg := range []string{"pull request created", "pull request has fewer reviewers than wanted"} {
+		line := findLogLine(lines, msg)
+
+		if line == nil {
+			t.Errorf("expected %q to be logged", msg)
+			continue
+		}
+
+		if line["request_id"] != "req-42" || line["route"] != "/pullRequest/create" ||
+			line["pr_id"] != "pr-log" || line["user_id"] != "u30" {
+			t.Errorf("expected %q to carry the request context, got %v", msg, line)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/models"
+	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+)
+
+func TestMemoryStoreCreatePR(t *testing.T) {
+	env := SetupMemoryEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 6)
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-7000", "Add feature", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	pr, err := env.Store.GetPR(context.Background(), "pr-7000")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	if len(pr.AssignedReviewers) != 2 {
+		t.Fatalf("expected 2 reviewers, got %v", pr.AssignedReviewers)
+	}
+
+	for _, reviewer := range pr.AssignedReviewers {
+		if reviewer == "u30" {
+			t.Errorf("author should not review their own PR, got %v", pr.AssignedReviewers)
+		}
+	}
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-7000", "Add feature", "u30"); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for duplicate PR, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMemoryStoreLeastLoadedSpreadsReviews(t *testing.T) {
+	env := SetupMemoryEnvironment(t)
+	defer env.Cleanup()
+
+	addTestTeam(t, env, `{
+		"team_name": "backend",
+		"reviewer_strategy": "least_loaded",
+		"members": [
+			{"user_id": "u30", "username": "User30", "is_active": true},
+			{"user_id": "u31", "username": "User31", "is_active": true},
+			{"user_id": "u32", "username": "User32", "is_active": true},
+			{"user_id": "u33", "username": "User33", "is_active": true},
+			{"user_id": "u34", "username": "User34", "is_active": true}
+		]
+	}`)
+
+	for _, prID := range []string{"pr-7100", "pr-7101"} {
+		if w := CreateTestPR(t, env.PRHandler, prID, "Refactor", "u30"); w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	load, err := env.Store.GetOpenReviewLoad(context.Background(), "backend")
+	if err != nil {
+		t.Fatalf("failed to get review load: %v", err)
+	}
+
+	for _, id := range []string{"u31", "u32", "u33", "u34"} {
+		if load[id] != 1 {
+			t.Errorf("expected %s to review exactly one PR, got load %v", id, load)
+		}
+	}
+}
+
+func TestMemoryStoreSentinelErrors(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+
+	team := &models.Team{
+		TeamName:     "backend",
+		MaxReviewers: 2,
+		Members: []models.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+		},
+	}
+
+	if err := store.CreateTeam(ctx, team); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	if err := store.CreateTeam(ctx, team); !errors.Is(err, storage.ErrTeamExists) {
+		t.Errorf("expected ErrTeamExists, got %v", err)
+	}
+
+	if _, err := store.GetTeam(ctx, "frontend"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	pr := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		PullRequestName:   "Add feature",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2"},
+	}
+
+	if err := store.CreatePR(ctx, pr); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	if err := store.CreatePR(ctx, pr); !errors.Is(err, storage.ErrPRExists) {
+		t.Errorf("expected ErrPRExists, got %v", err)
+	}
+
+	if err := store.ReassignReviewer(ctx, "pr-1", "u1", "", "", ""); !errors.Is(err, storage.ErrNotAssigned) {
+		t.Errorf("expected ErrNotAssigned, got %v", err)
+	}
+
+	review := &models.Review{ReviewerID: "u1", Decision: models.ReviewApproved}
+	if err := store.AddReview(ctx, "pr-1", review); !errors.Is(err, storage.ErrNotAssigned) {
+		t.Errorf("expected ErrNotAssigned, got %v", err)
+	}
+
+	refused := errors.New("refused")
+	if _, err := store.MergePR(ctx, "pr-1", func(pr *models.PullRequest) (bool, string, error) {
+		return false, "", refused
+	}); !errors.Is(err, refused) {
+		t.Errorf("expected the check to refuse the merge, got %v", err)
+	}
+
+	if pr, err := store.GetPR(ctx, "pr-1"); err != nil || pr.Status != models.PRStatusOpen {
+		t.Fatalf("expected the refused PR to stay OPEN, got %v, %v", pr, err)
+	}
+
+	merged, err := store.MergePR(ctx, "pr-1", func(pr *models.PullRequest) (bool, string, error) {
+		return true, "hotfix", nil
+	})
+	if err != nil {
+		t.Fatalf("failed to merge PR: %v", err)
+	}
+
+	if !merged.ForceMerged || merged.ForceMergeReason != "hotfix" {
+		t.Errorf("expected the check's outcome to be recorded, got %+v", merged)
+	}
+
+	if _, err := store.ClosePR(ctx, "pr-1"); !errors.Is(err, storage.ErrStatusConflict) {
+		t.Errorf("expected ErrStatusConflict, got %v", err)
+	}
+}
+
+func TestMemoryStoreReassignmentsAreAtomic(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+
+	team := &models.Team{
+		TeamName:     "backend",
+		MaxReviewers: 2,
+		Members: []models.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+			{UserID: "u3", Username: "Carol", IsActive: true},
+		},
+	}
+
+	if err := store.CreateTeam(ctx, team); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+
+	pr := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		PullRequestName:   "Add feature",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2"},
+	}
+
+	if err := store.CreatePR(ctx, pr); err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	changes := []models.ReviewReassignment{
+		{PullRequestID: "pr-1", OldReviewerID: "u2", NewReviewerID: "u3"},
+		{PullRequestID: "pr-1", OldReviewerID: "u2"},
+	}
+
+	err := store.DeactivateUsersWithReassignments(ctx, []string{"u2"}, changes)
+	if !errors.Is(err, storage.ErrNotAssigned) {
+		t.Fatalf("expected ErrNotAssigned, got %v", err)
+	}
+
+	got, err := store.GetPR(ctx, "pr-1")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	if len(got.AssignedReviewers) != 1 || got.AssignedReviewers[0] != "u2" {
+		t.Errorf("expected the failed batch to leave reviewers untouched, got %v", got.AssignedReviewers)
+	}
+
+	user, err := store.GetUser(ctx, "u2")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	if !user.IsActive {
+		t.Error("expected the failed batch to leave the user active")
+	}
+
+	// A replacement assigned concurrently is a conflict, not an internal error.
+	err = store.CreatePR(ctx, &models.PullRequest{
+		PullRequestID:     "pr-2",
+		PullRequestName:   "Add another feature",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2", "u3"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create PR: %v", err)
+	}
+
+	if err := store.ReassignReviewer(ctx, "pr-2", "u2", "u3", "", ""); !errors.Is(err, storage.ErrStatusConflict) {
+		t.Errorf("expected ErrStatusConflict, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMergeBlockedByRules(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+
+	w := updateTestTeamSettings(t, env, `{
+		"team_name": "backend",
+		"required_approvals": 1,
+		"block_on_changes_requested": true,
+		"require_all_reviewers": true
+	}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = CreateTestPR(t, env.PRHandler, "pr-9600", "Gated", "u30")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reviewers := decodePR(t, w)["assigned_reviewers"].([]interface{})
+
+	if w := SubmitTestReview(t, env, "pr-9600", reviewers[0].(string), "CHANGES_REQUESTED"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w2 := postPRTransition(t, env.PRHandler.MergePR, "/pullRequest/merge", "pr-9600")
+
+	if w2.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w2.Code, w2.Body.String())
+	}
+
+	var errorResponse map[string]interface{}
+	if err := json.NewDecoder(w2.Body).Decode(&errorResponse); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+
+	errorObj := errorResponse["error"].(map[string]interface{})
+
+	if errorObj["code"] != "MERGE_BLOCKED" {
+		t.Errorf("expected error code MERGE_BLOCKED, got %v", errorObj["code"])
+	}
+
+	unmet := errorObj["details"].(map[string]interface{})["unmet_rules"].([]interface{})
+	rules := make(map[string]bool)
+	for _, rule := range unmet {
+		rules[rule.(map[string]interface{})["rule"].(string)] = true
+	}
+
+	for _, rule := range []string{"min_approvals", "no_changes_requested", "all_reviewers_responded"} {
+		if !rules[rule] {
+			t.Errorf("expected unmet rule %s, got %v", rule, unmet)
+		}
+	}
+
+	payload := `{"pull_request_id": "pr-9600", "force": true, "reason": "hotfix"}`
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w3 := httptest.NewRecorder()
+
+	env.PRHandler.MergePR(w3, req)
+
+	if w3.Code != http.StatusOK {
+		t.Fatalf("expected 200 for forced merge, got %d: %s", w3.Code, w3.Body.String())
+	}
+
+	pr := decodePR(t, w3)
+
+	if pr["status"] != "MERGED" || pr["force_merged"] != true || pr["force_merge_reason"] != "hotfix" {
+		t.Errorf("expected forced merge to be recorded, got %v", pr)
+	}
+}
+
+func TestForceMergeIsOnlyFlaggedWhenRulesAreBypassed(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+
+	for _, id := range []string{"pr-9610", "pr-9611"} {
+		if w := CreateTestPR(t, env.PRHandler, id, "Forced", "u30"); w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	forceMerge := func(payload string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		env.PRHandler.MergePR(w, req)
+
+		return w
+	}
+
+	w := forceMerge(`{"pull_request_id": "pr-9610", "force": true, "reason": "just because"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if pr := decodePR(t, w); pr["force_merged"] == true || pr["force_merge_reason"] != nil {
+		t.Errorf("expected a merge that met every rule not to be flagged as forced, got %v", pr)
+	}
+
+	if w := updateTestTeamSettings(t, env, `{"team_name": "backend", "required_approvals": 1}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := forceMerge(`{"pull_request_id": "pr-9611", "force": true}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for bypassing rules without a reason, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCommentDoesNotWithdrawApproval(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+
+	if w := updateTestTeamSettings(t, env, `{"team_name": "backend", "required_approvals": 1}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := CreateTestPR(t, env.PRHandler, "pr-9620", "Commented", "u30")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reviewer := decodePR(t, w)["assigned_reviewers"].([]interface{})[0].(string)
+
+	for _, decision := range []string{"APPROVED", "COMMENTED"} {
+		if w := SubmitTestReview(t, env, "pr-9620", reviewer, decision); w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	if w := postPRTransition(t, env.PRHandler.MergePR, "/pullRequest/merge", "pr-9620"); w.Code != http.StatusOK {
+		t.Errorf("expected the approval to stand after a comment, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPRNeedsAsManyReviewersAsRequiredApprovals(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	addTestTeam(t, env, `{
+		"team_name": "tiny",
+		"members": [
+			{"user_id": "t1", "username": "T1", "is_active": true},
+			{"user_id": "t2", "username": "T2", "is_active": true}
+		]
+	}`)
+
+	if w := updateTestTeamSettings(t, env, `{"team_name": "tiny", "min_reviewers": 0, "required_approvals": 2}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-9630", "Unmergeable", "t1"); w.Code != http.StatusConflict {
+		t.Errorf("expected a PR short of required approvals to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+}
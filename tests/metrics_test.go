@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricValue returns the value of a counter, or the sample count of a
+// histogram, from the default registry. Collectors are process-wide, so tests
+// compare values before and after instead of expecting absolute ones.
+func metricValue(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+	next:
+		for _, m := range family.GetMetric() {
+			for _, pair := range m.GetLabel() {
+				if want, ok := labels[pair.GetName()]; ok && want != pair.GetValue() {
+					continue next
+				}
+			}
+
+			if m.GetHistogram() != nil {
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+
+			return m.GetCounter().GetValue()
+		}
+	}
+
+	return 0
+}
+
+func TestHTTPMetricsMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/teapot/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	mux.Handle("/metrics", promhttp.Handler())
+
+	handler := metrics.Middleware(mux, mux)
+
+	teapot := map[string]string{"method": "GET", "route": "/teapot/{id}", "status": "418"}
+	unmatched := map[string]string{"method": "OTHER", "route": "unmatched", "status": "404"}
+
+	requestsBefore := metricValue(t, "pr_reviewer_http_requests_total", teapot)
+	latencyBefore := metricValue(t, "pr_reviewer_http_request_duration_seconds", teapot)
+	unmatchedBefore := metricValue(t, "pr_reviewer_http_requests_total", unmatched)
+
+	for _, id := range []string{"1", "2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/teapot/"+id, nil))
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/nowhere", nil))
+
+	if got := metricValue(t, "pr_reviewer_http_requests_total", teapot) - requestsBefore; got != 2 {
+		t.Errorf("expected both requests to be counted under the route pattern, got %v", got)
+	}
+
+	if got := metricValue(t, "pr_reviewer_http_request_duration_seconds", teapot) - latencyBefore; got != 2 {
+		t.Errorf("expected 2 latency observations, got %v", got)
+	}
+
+	if got := metricValue(t, "pr_reviewer_http_requests_total", unmatched) - unmatchedBefore; got != 1 {
+		t.Errorf("expected the unknown path and method to be folded together, got %v", got)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(w.Body)
+
+	if w.Code != http.StatusOK || !strings.Contains(string(body), `pr_reviewer_http_requests_total{method="GET",route="/teapot/{id}",status="418"}`) {
+		t.Errorf("expected /metrics to expose the request counter, got %d:\n%s", w.Code, body)
+	}
+}
+
+func TestDomainMetrics(t *testing.T) {
+	env := SetupMemoryEnvironment(t)
+	defer env.Cleanup()
+
+	created := metricValue(t, "pr_reviewer_prs_created_total", nil)
+	understaffed := metricValue(t, "pr_reviewer_prs_understaffed_total", nil)
+	merged := metricValue(t, "pr_reviewer_prs_merged_total", map[string]string{"forced": "false"})
+	noCandidate := metricValue(t, "pr_reviewer_no_candidate_total", map[string]string{"trigger": "manual"})
+	reassigned := metricValue(t, "pr_reviewer_reassignments_total", map[string]string{"trigger": "deactivation"})
+
+	// With two members the author's single teammate is the only reviewer.
+	CreateTestTeam(t, env.TeamHandler, "metrics", 2)
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-metrics", "Metrics", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/reassign",
+		bytes.NewBufferString(`{"pull_request_id": "pr-metrics", "old_user_id": "u31"}`))
+	w := httptest.NewRecorder()
+	env.PRHandler.ReassignReviewer(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected NO_CANDIDATE, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/pullRequest/merge",
+		bytes.NewBufferString(`{"pull_request_id": "pr-metrics"}`))
+	w = httptest.NewRecorder()
+	env.PRHandler.MergePR(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Merging again is a no-op and must not be counted twice.
+	env.PRHandler.MergePR(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/pullRequest/merge",
+		bytes.NewBufferString(`{"pull_request_id": "pr-metrics"}`)))
+
+	deltas := map[string]float64{
+		"created":      metricValue(t, "pr_reviewer_prs_created_total", nil) - created,
+		"understaffed": metricValue(t, "pr_reviewer_prs_understaffed_total", nil) - understaffed,
+		"merged":       metricValue(t, "pr_reviewer_prs_merged_total", map[string]string{"forced": "false"}) - merged,
+		"no_candidate": metricValue(t, "pr_reviewer_no_candidate_total", map[string]string{"trigger": "manual"}) - noCandidate,
+	}
+
+	for name, delta := range deltas {
+		if delta != 1 {
+			t.Errorf("expected %s to go up by 1, got %v", name, delta)
+		}
+	}
+
+	// Deactivating the author's reviewer on a fresh PR with spare members
+	// moves the review and is counted under its own trigger.
+	w = httptest.NewRecorder()
+	env.TeamHandler.AddTeam(w, httptest.NewRequest(http.MethodPost, "/team/add", bytes.NewBufferString(`{
+		"team_name": "metrics-wide",
+		"members": [
+			{"user_id": "mw0", "username": "MW0", "is_active": true},
+			{"user_id": "mw1", "username": "MW1", "is_active": true},
+			{"user_id": "mw2", "username": "MW2", "is_active": true},
+			{"user_id": "mw3", "username": "MW3", "is_active": true}
+		]
+	}`)))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create team: %d %s", w.Code, w.Body.String())
+	}
+
+	w = CreateTestPR(t, env.PRHandler, "pr-metrics-wide", "Metrics", "mw0")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var reviewer string
+	for _, id := range []string{"mw1", "mw2", "mw3"} {
+		if strings.Contains(w.Body.String(), `"`+id+`"`) {
+			reviewer = id
+			break
+		}
+	}
+
+	w = httptest.NewRecorder()
+	env.UserHandler.SetUserActive(w, httptest.NewRequest(http.MethodPost, "/users/setIsActive",
+		bytes.NewBufferString(fmt.Sprintf(`{"user_id": %q, "is_active": false}`, reviewer))))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to deactivate reviewer: %d %s", w.Code, w.Body.String())
+	}
+
+	if got := metricValue(t, "pr_reviewer_reassignments_total", map[string]string{"trigger": "deactivation"}) - reassigned; got != 1 {
+		t.Errorf("expected one reassignment on deactivation, got %v", got)
+	}
+}
@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/migrate"
+	"github.com/Jersonmade/pr-reviewer-service/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	loaded, err := migrate.Load(fstest.MapFS{
+		"002_second.sql":     {Data: []byte("SELECT 2;")},
+		"001_first.sql":      {Data: []byte("SELECT 1;")},
+		"001_first.down.sql": {Data: []byte("SELECT -1;")},
+		"migrations.go":      {Data: []byte("package migrations")},
+	})
+
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	if len(loaded) != 2 || loaded[0].Version != 1 || loaded[1].Version != 2 {
+		t.Fatalf("expected versions 1 and 2 in order, got %+v", loaded)
+	}
+
+	if loaded[0].Name != "first" || loaded[0].Down != "SELECT -1;" || loaded[1].Down != "" {
+		t.Errorf("unexpected migrations: %+v", loaded)
+	}
+
+	invalid := map[string]fstest.MapFS{
+		"duplicate version": {"001_a.sql": {}, "001_b.sql": {}},
+		"orphan down":       {"001_a.sql": {}, "002_b.down.sql": {}},
+		"no version":        {"init.sql": {}},
+	}
+
+	for name, fsys := range invalid {
+		if _, err := migrate.Load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsAreReversible(t *testing.T) {
+	loaded, err := migrate.Load(migrations.Files)
+
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	for i, m := range loaded {
+		if m.Version != i+1 {
+			t.Errorf("expected version %d, got %d (%s)", i+1, m.Version, m.Name)
+		}
+
+		if m.Down == "" {
+			t.Errorf("migration %03d_%s has no down script", m.Version, m.Name)
+		}
+	}
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	store, cleanup := startTestPostgres(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	loaded, err := migrate.Load(migrations.Files)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	applied := make([][]migrate.Migration, 2)
+	errs := make([]error, 2)
+
+	for i := range applied {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			migrator, err := migrate.New(store.DB(), migrations.Files, discardLogger)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			applied[i], errs[i] = migrator.Up(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("migrator %d failed: %v", i, err)
+		}
+	}
+
+	if total := len(applied[0]) + len(applied[1]); total != len(loaded) {
+		t.Fatalf("expected concurrent runs to apply %d migrations once, got %d", len(loaded), total)
+	}
+
+	migrator, err := migrate.New(store.DB(), migrations.Files, discardLogger)
+	if err != nil {
+		t.Fatalf("failed to create migrator: %v", err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("expected %03d_%s to be applied", status.Version, status.Name)
+		}
+	}
+
+	if pending, err := migrator.Pending(ctx); err != nil || len(pending) != 0 {
+		t.Fatalf("expected no pending migrations, got %v, %v", pending, err)
+	}
+
+	for range loaded {
+		reverted, err := migrator.Down(ctx)
+
+		if err != nil {
+			t.Fatalf("failed to revert: %v", err)
+		}
+
+		if reverted == nil {
+			t.Fatal("expected a migration to be reverted")
+		}
+	}
+
+	if reverted, err := migrator.Down(ctx); err != nil || reverted != nil {
+		t.Fatalf("expected nothing left to revert, got %v, %v", reverted, err)
+	}
+
+	if pending, err := migrator.Pending(ctx); err != nil || len(pending) != len(loaded) {
+		t.Fatalf("expected every migration to be pending, got %d, %v", len(pending), err)
+	}
+
+	reapplied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("failed to re-apply migrations: %v", err)
+	}
+
+	if len(reapplied) != len(loaded) {
+		t.Errorf("expected %d migrations to be re-applied, got %d", len(loaded), len(reapplied))
+	}
+}
+
+// schema describes every column and constraint, so that migrations reverted
+// and re-applied can be checked to leave the schema as it was.
+func schema(t *testing.T, db *sql.DB) string {
+	var columns, constraints string
+
+	err := db.QueryRow(`
+		SELECT string_agg(
+			table_name || '.' || column_name || ' ' || data_type || ' ' || is_nullable || ' ' || COALESCE(column_default, ''),
+			E'\n' ORDER BY table_name, column_name
+		)
+		FROM information_schema.columns
+		WHERE table_schema = 'public'
+	`).Scan(&columns)
+
+	if err != nil {
+		t.Fatalf("failed to list columns: %v", err)
+	}
+
+	err = db.QueryRow(`
+		SELECT string_agg(
+			conrelid::regclass || ' ' || conname || ' ' || pg_get_constraintdef(oid),
+			E'\n' ORDER BY conrelid::regclass::text, conname
+		)
+		FROM pg_constraint
+		WHERE connamespace = 'public'::regnamespace
+	`).Scan(&constraints)
+
+	if err != nil {
+		t.Fatalf("failed to list constraints: %v", err)
+	}
+
+	return columns + "\n" + constraints
+}
+
+func TestMigrateDownStepByStep(t *testing.T) {
+	store, cleanup := startTestPostgres(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	migrator, err := migrate.New(store.DB(), migrations.Files, discardLogger)
+	if err != nil {
+		t.Fatalf("failed to create migrator: %v", err)
+	}
+
+	loaded, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+
+	want := schema(t, store.DB())
+
+	// Each round reverts one more migration than the last, so every down
+	// script is checked against the up scripts of the versions below it.
+	for steps := 1; steps <= len(loaded); steps++ {
+		for i := 0; i < steps; i++ {
+			if _, err := migrator.Down(ctx); err != nil {
+				t.Fatalf("round %d: failed to revert: %v", steps, err)
+			}
+		}
+
+		reapplied, err := migrator.Up(ctx)
+		if err != nil {
+			t.Fatalf("round %d: failed to re-apply migrations: %v", steps, err)
+		}
+
+		if len(reapplied) != steps {
+			t.Fatalf("round %d: expected %d migrations to be re-applied, got %d", steps, steps, len(reapplied))
+		}
+
+		if got := schema(t, store.DB()); got != want {
+			t.Fatalf("round %d: schema differs after reverting down to %03d and re-applying:\n%s\nwant:\n%s",
+				steps, reapplied[0].Version, got, want)
+		}
+	}
+}
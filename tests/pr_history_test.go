@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPRHistory(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 5)
+
+	w := CreateTestPR(t, env.PRHandler, "pr-9700", "Audited", "u30")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	oldReviewerID := decodePR(t, w)["assigned_reviewers"].([]interface{})[0].(string)
+
+	payload, _ := json.Marshal(map[string]string{
+		"pull_request_id": "pr-9700",
+		"old_user_id":     oldReviewerID,
+		"reason":          "on vacation",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/reassign", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w2 := httptest.NewRecorder()
+
+	env.PRHandler.ReassignReviewer(w2, req)
+
+	if w2.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w2.Code, w2.Body.String())
+	}
+
+	if w := postPRTransition(t, env.PRHandler.MergePR, "/pullRequest/merge", "pr-9700"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/pullRequest/history?pull_request_id=pr-9700", nil)
+	w3 := httptest.NewRecorder()
+
+	env.PRHandler.GetPRHistory(w3, req)
+
+	if w3.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w3.Code, w3.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w3.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	events := response["events"].([]interface{})
+	expected := []string{
+		"PR_CREATED",
+		"REVIEWER_ASSIGNED",
+		"REVIEWER_ASSIGNED",
+		"REVIEWER_UNASSIGNED",
+		"REVIEWER_ASSIGNED",
+		"STATUS_CHANGED",
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %v", len(expected), len(events), events)
+	}
+
+	for i, eventType := range expected {
+		event := events[i].(map[string]interface{})
+
+		if event["event_type"] != eventType {
+			t.Errorf("event %d: expected %s, got %v", i, eventType, event["event_type"])
+		}
+	}
+
+	unassigned := events[3].(map[string]interface{})
+
+	if unassigned["user_id"] != oldReviewerID || unassigned["reason"] != "on vacation" {
+		t.Errorf("unexpected unassignment event %v", unassigned)
+	}
+
+	merged := events[5].(map[string]interface{})
+
+	if merged["from_status"] != "OPEN" || merged["to_status"] != "MERGED" {
+		t.Errorf("unexpected status event %v", merged)
+	}
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func postPRTransition(t *testing.T, handler http.HandlerFunc, path, prID string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, _ := json.Marshal(map[string]string{"pull_request_id": prID})
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	return w
+}
+
+func decodePR(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	return response["pr"].(map[string]interface{})
+}
+
+func TestDraftGetsReviewersWhenReady(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+
+	payload := `{"pull_request_id": "pr-9000", "pull_request_name": "WIP", "author_id": "u30", "draft": true}`
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.PRHandler.CreatePR(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	pr := decodePR(t, w)
+
+	if pr["status"] != "DRAFT" {
+		t.Errorf("expected status DRAFT, got %v", pr["status"])
+	}
+
+	if reviewers := pr["assigned_reviewers"].([]interface{}); len(reviewers) != 0 {
+		t.Errorf("draft should have no reviewers, got %v", reviewers)
+	}
+
+	w2 := postPRTransition(t, env.PRHandler.MergePR, "/pullRequest/merge", "pr-9000")
+
+	if w2.Code != http.StatusConflict {
+		t.Errorf("expected 409 when merging a draft, got %d", w2.Code)
+	}
+
+	w3 := postPRTransition(t, env.PRHandler.MarkReady, "/pullRequest/ready", "pr-9000")
+
+	if w3.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w3.Code, w3.Body.String())
+	}
+
+	pr = decodePR(t, w3)
+
+	if pr["status"] != "OPEN" {
+		t.Errorf("expected status OPEN, got %v", pr["status"])
+	}
+
+	if reviewers := pr["assigned_reviewers"].([]interface{}); len(reviewers) != 2 {
+		t.Errorf("expected 2 reviewers after ready, got %v", reviewers)
+	}
+}
+
+func TestDraftRejectsReviewersCount(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+
+	payload := `{"pull_request_id": "pr-9001", "pull_request_name": "WIP", "author_id": "u30", "draft": true, "reviewers_count": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.PRHandler.CreatePR(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for reviewers_count on a draft, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCloseAndReopenPR(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-9100", "Abandoned", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := postPRTransition(t, env.PRHandler.ClosePR, "/pullRequest/close", "pr-9100")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	pr := decodePR(t, w)
+
+	if pr["status"] != "CLOSED" || pr["closedAt"] == nil {
+		t.Errorf("expected CLOSED with closedAt, got %v", pr)
+	}
+
+	w2 := postPRTransition(t, env.PRHandler.MergePR, "/pullRequest/merge", "pr-9100")
+
+	if w2.Code != http.StatusConflict {
+		t.Errorf("expected 409 when merging a closed PR, got %d", w2.Code)
+	}
+
+	w3 := postPRTransition(t, env.PRHandler.ReopenPR, "/pullRequest/reopen", "pr-9100")
+
+	if w3.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w3.Code, w3.Body.String())
+	}
+
+	pr = decodePR(t, w3)
+
+	if pr["status"] != "OPEN" {
+		t.Errorf("expected status OPEN, got %v", pr["status"])
+	}
+
+	if reviewers := pr["assigned_reviewers"].([]interface{}); len(reviewers) != 2 {
+		t.Errorf("expected original reviewers to be kept, got %v", reviewers)
+	}
+
+	w4 := postPRTransition(t, env.PRHandler.ReopenPR, "/pullRequest/reopen", "pr-9100")
+
+	if w4.Code != http.StatusConflict {
+		t.Errorf("expected 409 when reopening an open PR, got %d", w4.Code)
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func SubmitTestReview(t *testing.T, env *TestEnvironment, prID, reviewerID, decision string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, _ := json.Marshal(map[string]string{
+		"pull_request_id": prID,
+		"reviewer_id":     reviewerID,
+		"decision":        decision,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/review", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.PRHandler.SubmitReview(w, req)
+
+	return w
+}
+
+func TestMergeRequiresApprovals(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+
+	if w := updateTestTeamSettings(t, env, `{"team_name": "backend", "required_approvals": 1}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := CreateTestPR(t, env.PRHandler, "pr-9500", "Reviewed", "u30")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reviewers := decodePR(t, w)["assigned_reviewers"].([]interface{})
+
+	w2 := postPRTransition(t, env.PRHandler.MergePR, "/pullRequest/merge", "pr-9500")
+
+	if w2.Code != http.StatusConflict {
+		t.Fatalf("expected 409 without approvals, got %d: %s", w2.Code, w2.Body.String())
+	}
+
+	if w := SubmitTestReview(t, env, "pr-9500", "u30", "APPROVED"); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for author approving own PR, got %d", w.Code)
+	}
+
+	w3 := SubmitTestReview(t, env, "pr-9500", reviewers[0].(string), "APPROVED")
+
+	if w3.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w3.Code, w3.Body.String())
+	}
+
+	reviews := decodePR(t, w3)["reviews"].([]interface{})
+
+	if len(reviews) != 1 {
+		t.Fatalf("expected 1 review, got %v", reviews)
+	}
+
+	if review := reviews[0].(map[string]interface{}); review["decision"] != "APPROVED" || review["submittedAt"] == nil {
+		t.Errorf("unexpected review %v", review)
+	}
+
+	w4 := postPRTransition(t, env.PRHandler.MergePR, "/pullRequest/merge", "pr-9500")
+
+	if w4.Code != http.StatusOK {
+		t.Errorf("expected 200 after approval, got %d: %s", w4.Code, w4.Body.String())
+	}
+}
+
+func TestSubmitReviewUnknownDecision(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 3)
+
+	w := CreateTestPR(t, env.PRHandler, "pr-9501", "Reviewed", "u30")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reviewers := decodePR(t, w)["assigned_reviewers"].([]interface{})
+
+	if w := SubmitTestReview(t, env, "pr-9501", reviewers[0].(string), "LGTM"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateTeamWithStrategy(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	payload := `{
+		"team_name": "platform",
+		"reviewer_strategy": "weighted",
+		"members": [
+			{"user_id": "u30", "username": "User30", "is_active": true},
+			{"user_id": "u31", "username": "User31", "is_active": true, "review_weight": 5},
+			{"user_id": "u32", "username": "User32", "is_active": true}
+		]
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/team/add", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.TeamHandler.AddTeam(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	team := response["team"].(map[string]interface{})
+
+	if team["reviewer_strategy"] != "weighted" {
+		t.Errorf("expected strategy weighted, got %v", team["reviewer_strategy"])
+	}
+
+	w2 := CreateTestPR(t, env.PRHandler, "pr-6000", "Weighted", "u30")
+
+	if w2.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w2.Code, w2.Body.String())
+	}
+}
+
+func TestCreateTeamWithUnknownStrategy(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	payload := `{
+		"team_name": "platform",
+		"reviewer_strategy": "alphabetical",
+		"members": [{"user_id": "u30", "username": "User30", "is_active": true}]
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/team/add", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.TeamHandler.AddTeam(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRoundRobinRotatesReviewers(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	payload := `{
+		"team_name": "backend",
+		"reviewer_strategy": "round_robin",
+		"members": [
+			{"user_id": "u30", "username": "User30", "is_active": true},
+			{"user_id": "u31", "username": "User31", "is_active": true},
+			{"user_id": "u32", "username": "User32", "is_active": true},
+			{"user_id": "u33", "username": "User33", "is_active": true},
+			{"user_id": "u34", "username": "User34", "is_active": true}
+		]
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/team/add", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.TeamHandler.AddTeam(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create team: %d - %s", w.Code, w.Body.String())
+	}
+
+	seen := make(map[string]int)
+
+	for i := 0; i < 2; i++ {
+		w := CreateTestPR(t, env.PRHandler, fmt.Sprintf("pr-61%02d", i), "Rotation", "u30")
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var response map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		for _, reviewer := range response["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{}) {
+			seen[reviewer.(string)]++
+		}
+	}
+
+	if len(seen) != 4 {
+		t.Errorf("expected all 4 non-author members to be picked once, got %v", seen)
+	}
+}
+
+func TestLeastLoadedPrefersIdleReviewer(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	payload := `{
+		"team_name": "backend",
+		"reviewer_strategy": "least_loaded",
+		"members": [
+			{"user_id": "u30", "username": "User30", "is_active": true},
+			{"user_id": "u31", "username": "User31", "is_active": true},
+			{"user_id": "u32", "username": "User32", "is_active": true},
+			{"user_id": "u33", "username": "User33", "is_active": true}
+		]
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/team/add", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.TeamHandler.AddTeam(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create team: %d - %s", w.Code, w.Body.String())
+	}
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-6200", "First", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	first, err := env.Store.GetPR(context.Background(), "pr-6200")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	idle := map[string]bool{"u31": true, "u32": true, "u33": true}
+	for _, reviewer := range first.AssignedReviewers {
+		delete(idle, reviewer)
+	}
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-6201", "Second", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	second, err := env.Store.GetPR(context.Background(), "pr-6201")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	for id := range idle {
+		found := false
+		for _, reviewer := range second.AssignedReviewers {
+			if reviewer == id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Errorf("expected idle member %s to be assigned, got %v", id, second.AssignedReviewers)
+		}
+	}
+}
+
+func TestExplicitZeroReviewWeightIsKept(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	addTestTeam(t, env, `{
+		"team_name": "platform",
+		"reviewer_strategy": "weighted",
+		"members": [
+			{"user_id": "u30", "username": "User30", "is_active": true},
+			{"user_id": "u31", "username": "User31", "is_active": true, "review_weight": 0},
+			{"user_id": "u32", "username": "User32", "is_active": true}
+		]
+	}`)
+
+	weights, err := env.Store.GetReviewWeights(context.Background(), []string{"u31", "u32"})
+	if err != nil {
+		t.Fatalf("failed to get weights: %v", err)
+	}
+
+	if weights["u31"] != 0 || weights["u32"] != 1 {
+		t.Fatalf("expected an explicit 0 to be kept and a missing weight to default to 1, got %v", weights)
+	}
+
+	for i := 0; i < 5; i++ {
+		payload := fmt.Sprintf(`{"pull_request_id": "pr-610%d", "pull_request_name": "Weighted", "author_id": "u30", "reviewers_count": 1}`, i)
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		env.PRHandler.CreatePR(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+
+		if reviewers := decodePR(t, w)["assigned_reviewers"].([]interface{}); len(reviewers) != 1 || reviewers[0] != "u32" {
+			t.Errorf("expected the zero-weight member to be skipped, got %v", reviewers)
+		}
+	}
+}
@@ -3,21 +3,24 @@ package tests
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/Jersonmade/pr-reviewer-service/internal/handlers"
+	"github.com/Jersonmade/pr-reviewer-service/internal/migrate"
 	"github.com/Jersonmade/pr-reviewer-service/internal/services"
 	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"github.com/Jersonmade/pr-reviewer-service/migrations"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
 type TestEnvironment struct {
-	Store       *storage.PostgresStorage
+	Store       storage.Store
 	TeamHandler *handlers.TeamHandler
 	PRHandler   *handlers.PRHandler
 	UserHandler *handlers.UserHandler
@@ -29,13 +32,27 @@ func SetupTestEnvironment(t *testing.T) *TestEnvironment {
 
 	store, cleanup := setupTestDB(t)
 
-	teamService := services.NewTeamService(store)
+	return newTestEnvironment(store, cleanup)
+}
+
+// SetupMemoryEnvironment wires the handlers to an in-memory store, for tests
+// that exercise service logic and do not need a real database.
+func SetupMemoryEnvironment(t *testing.T) *TestEnvironment {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+
+	return newTestEnvironment(store, func() { _ = store.Close() })
+}
+
+func newTestEnvironment(store storage.Store, cleanup func()) *TestEnvironment {
+	teamService := services.NewTeamService(store, services.DefaultOptions(), discardLogger)
 	userService := services.NewUserService(store)
-	prService := services.NewPRService(store, userService)
+	prService := services.NewPRService(store, userService, services.DefaultOptions(), discardLogger)
 
-	teamHandler := handlers.NewTeamHandler(teamService)
-	prHandler := handlers.NewPRHandler(prService)
-	userHandler := handlers.NewUserHandler(userService, prService)
+	teamHandler := handlers.NewTeamHandler(teamService, prService, discardLogger)
+	prHandler := handlers.NewPRHandler(prService, discardLogger)
+	userHandler := handlers.NewUserHandler(userService, prService, discardLogger)
 
 	return &TestEnvironment{
 		Store:       store,
@@ -47,22 +64,32 @@ func SetupTestEnvironment(t *testing.T) *TestEnvironment {
 }
 
 func setupTestDB(t *testing.T) (*storage.PostgresStorage, func()) {
-	ctx := context.Background()
+	store, cleanup := startTestPostgres(t)
 
-	wd, err := os.Getwd()
+	migrator, err := migrate.New(store.DB(), migrations.Files, discardLogger)
 
 	if err != nil {
-		t.Fatalf("failed to get working directory: %v", err)
+		cleanup()
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	if _, err := migrator.Up(context.Background()); err != nil {
+		cleanup()
+		t.Fatalf("failed to apply migrations: %v", err)
 	}
 
-	initScriptPath := filepath.Join(wd, "..", "migrations", "001_init.sql")
+	return store, cleanup
+}
+
+// startTestPostgres starts an empty database; setupTestDB also migrates it.
+func startTestPostgres(t *testing.T) (*storage.PostgresStorage, func()) {
+	ctx := context.Background()
 
 	postgresContainer, err := postgres.Run(ctx,
 		"postgres:15-alpine",
 		postgres.WithDatabase("pr_reviewer_service"),
 		postgres.WithUsername("postgres"),
 		postgres.WithPassword("postgres"),
-		postgres.WithInitScripts(initScriptPath),
 		testcontainers.WithWaitStrategy(
 			wait.ForLog("database system is ready to accept connections").
 				WithOccurrence(2).
@@ -78,7 +105,7 @@ func setupTestDB(t *testing.T) (*storage.PostgresStorage, func()) {
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		host, port.Port(), "postgres", "postgres", "pr_reviewer_service")
 
-	store, err := storage.NewPostgresStorage(connStr)
+	store, err := storage.NewPostgresStorage(connStr, storage.DefaultPoolOptions(), discardLogger)
 
 	if err != nil {
 		t.Fatalf("failed to connect to test db: %v", err)
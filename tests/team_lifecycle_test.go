@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRenameTeam(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 3)
+	addTestTeam(t, env, `{
+		"team_name": "frontend",
+		"backup_teams": ["backend"],
+		"members": [{"user_id": "u50", "username": "User50", "is_active": true}]
+	}`)
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-9000", "Feature", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	before, err := env.Store.GetTeam(context.Background(), "backend")
+	if err != nil {
+		t.Fatalf("failed to get team: %v", err)
+	}
+
+	w, response := postTeamMembership(t, env.TeamHandler.RenameTeam, `{"team_name": "backend", "new_team_name": "platform"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	team := response["team"].(map[string]interface{})
+
+	if team["team_name"] != "platform" || int(team["min_reviewers"].(float64)) != before.MinReviewers {
+		t.Errorf("expected team to be renamed to platform with its settings, got %v", team)
+	}
+
+	if members := team["members"].([]interface{}); len(members) != 3 {
+		t.Errorf("expected members to follow the rename, got %d", len(members))
+	}
+
+	if _, err := env.Store.GetTeam(context.Background(), "backend"); err == nil {
+		t.Error("expected the old team name to be gone")
+	}
+
+	backups, err := env.Store.GetBackupTeams(context.Background(), "frontend")
+	if err != nil {
+		t.Fatalf("failed to get backup teams: %v", err)
+	}
+
+	if len(backups) != 1 || backups[0] != "platform" {
+		t.Errorf("expected backup link to follow the rename, got %v", backups)
+	}
+
+	if _, err := env.Store.GetPR(context.Background(), "pr-9000"); err != nil {
+		t.Errorf("expected PR to survive the rename: %v", err)
+	}
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-9001", "Feature", "u31"); w.Code != http.StatusCreated {
+		t.Errorf("expected renamed team to keep assigning reviewers, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, _ = postTeamMembership(t, env.TeamHandler.RenameTeam, `{"team_name": "platform", "new_team_name": "frontend"}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 when renaming onto an existing team, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDeleteTeam(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 3)
+	addTestTeam(t, env, `{
+		"team_name": "frontend",
+		"members": [{"user_id": "u50", "username": "User50", "is_active": true}]
+	}`)
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-9100", "Feature", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, response := postTeamMembership(t, env.TeamHandler.DeleteTeam, `{"team_name": "backend"}`)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while the team has open PRs, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if code := response["error"].(map[string]interface{})["code"]; code != "TEAM_HAS_OPEN_PRS" {
+		t.Errorf("expected TEAM_HAS_OPEN_PRS, got %v", code)
+	}
+
+	w, response = postTeamMembership(t, env.TeamHandler.DeleteTeam, `{"team_name": "backend", "target_team": "frontend"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if members := response["members"].([]interface{}); len(members) != 3 {
+		t.Errorf("expected 3 members to be moved, got %v", members)
+	}
+
+	user, err := env.Store.GetUser(context.Background(), "u30")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	if user.TeamName != "frontend" || !user.IsActive {
+		t.Errorf("expected u30 to be an active member of frontend, got %+v", user)
+	}
+
+	if _, err := env.Store.GetPR(context.Background(), "pr-9100"); err != nil {
+		t.Errorf("expected PR to survive the deletion: %v", err)
+	}
+
+	w, _ = postTeamMembership(t, env.TeamHandler.DeleteTeam, `{"team_name": "backend"}`)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a deleted team, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDeleteIdleTeamWithoutTarget(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 2)
+
+	w, response := postTeamMembership(t, env.TeamHandler.DeleteTeam, `{"team_name": "backend"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if members := response["members"].([]interface{}); len(members) != 2 {
+		t.Errorf("expected 2 members to be removed, got %v", members)
+	}
+
+	user, err := env.Store.GetUser(context.Background(), "u30")
+	if err != nil {
+		t.Fatalf("expected users to outlive their team: %v", err)
+	}
+
+	if user.TeamName != "" || user.IsActive {
+		t.Errorf("expected u30 to be inactive without a team, got %+v", user)
+	}
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func postTeamMembership(t *testing.T, handler http.HandlerFunc, payload string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(w.Body.Bytes())).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	return w, response
+}
+
+func TestAddAndRemoveTeamMembers(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 3)
+
+	w, response := postTeamMembership(t, env.TeamHandler.AddMembers, `{
+		"team_name": "backend",
+		"members": [{"user_id": "u40", "username": "User40", "is_active": true}]
+	}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	team := response["team"].(map[string]interface{})
+	if members := team["members"].([]interface{}); len(members) != 4 {
+		t.Errorf("expected 4 members, got %d", len(members))
+	}
+
+	addTestTeam(t, env, `{
+		"team_name": "frontend",
+		"members": [{"user_id": "u50", "username": "User50", "is_active": true}]
+	}`)
+
+	w, response = postTeamMembership(t, env.TeamHandler.AddMembers, `{
+		"team_name": "frontend",
+		"members": [{"user_id": "u31", "username": "User31", "is_active": true}]
+	}`)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a user of another team, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if code := response["error"].(map[string]interface{})["code"]; code != "MEMBER_EXISTS" {
+		t.Errorf("expected MEMBER_EXISTS, got %v", code)
+	}
+
+	w, _ = postTeamMembership(t, env.TeamHandler.RemoveMembers, `{"team_name": "backend", "user_ids": ["u40"]}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	user, err := env.Store.GetUser(context.Background(), "u40")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	if user.TeamName != "" || user.IsActive {
+		t.Errorf("expected removed user to be inactive without a team, got %+v", user)
+	}
+
+	w, _ = postTeamMembership(t, env.TeamHandler.AddMembers, `{
+		"team_name": "frontend",
+		"members": [{"user_id": "u40", "username": "User40", "is_active": true}]
+	}`)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected a removed user to be addable to another team, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRemoveMemberWithOpenPRs(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 3)
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-8000", "Feature", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, response := postTeamMembership(t, env.TeamHandler.RemoveMembers, `{"team_name": "backend", "user_ids": ["u30"]}`)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	errorObj := response["error"].(map[string]interface{})
+
+	if errorObj["code"] != "MEMBER_HAS_OPEN_PRS" {
+		t.Errorf("expected MEMBER_HAS_OPEN_PRS, got %v", errorObj["code"])
+	}
+
+	details := errorObj["details"].(map[string]interface{})
+	if prs := details["pull_requests"].([]interface{}); len(prs) != 1 || prs[0] != "pr-8000" {
+		t.Errorf("expected pr-8000 to be reported, got %v", prs)
+	}
+}
+
+func TestTransferUser(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "backend", 4)
+	addTestTeam(t, env, `{
+		"team_name": "frontend",
+		"members": [{"user_id": "u50", "username": "User50", "is_active": true}]
+	}`)
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-8100", "Feature", "u30"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	pr, err := env.Store.GetPR(context.Background(), "pr-8100")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	moving := pr.AssignedReviewers[0]
+
+	w, response := postTeamMembership(t, env.TeamHandler.TransferUser,
+		`{"user_id": "`+moving+`", "to_team": "frontend"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if response["from_team"] != "backend" || response["to_team"] != "frontend" {
+		t.Errorf("unexpected transfer result: %v", response)
+	}
+
+	if reassigned := response["reassigned"].([]interface{}); len(reassigned) != 1 {
+		t.Errorf("expected the in-flight review to be handed over, got %v", reassigned)
+	}
+
+	pr, err = env.Store.GetPR(context.Background(), "pr-8100")
+	if err != nil {
+		t.Fatalf("failed to get PR: %v", err)
+	}
+
+	for _, reviewer := range pr.AssignedReviewers {
+		if reviewer == moving {
+			t.Errorf("transferred user %s should no longer review pr-8100", moving)
+		}
+	}
+
+	w, response = postTeamMembership(t, env.TeamHandler.TransferUser,
+		`{"user_id": "u30", "to_team": "frontend", "keep_reviews": true}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if authored := response["authored_prs"].([]interface{}); len(authored) != 1 || authored[0] != "pr-8100" {
+		t.Errorf("expected pr-8100 to follow its author, got %v", authored)
+	}
+}
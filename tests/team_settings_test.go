@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func updateTestTeamSettings(t *testing.T, env *TestEnvironment, payload string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/team/settings", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	env.TeamHandler.UpdateTeamSettings(w, req)
+
+	return w
+}
+
+func TestCreatePRWithReviewersCount(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "platform", 6)
+
+	w := updateTestTeamSettings(t, env, `{"team_name": "platform", "max_reviewers": 3}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	payload := `{"pull_request_id": "pr-7000", "pull_request_name": "Docs", "author_id": "u30", "reviewers_count": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w2 := httptest.NewRecorder()
+
+	env.PRHandler.CreatePR(w2, req)
+
+	if w2.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w2.Code, w2.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w2.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	reviewers := response["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})
+
+	if len(reviewers) != 1 {
+		t.Errorf("expected 1 reviewer, got %d", len(reviewers))
+	}
+
+	w3 := CreateTestPR(t, env.PRHandler, "pr-7001", "Platform", "u30")
+
+	if w3.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w3.Code, w3.Body.String())
+	}
+
+	if err := json.NewDecoder(w3.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	reviewers = response["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})
+
+	if len(reviewers) != 3 {
+		t.Errorf("expected team default of 3 reviewers, got %d", len(reviewers))
+	}
+
+	payload = `{"pull_request_id": "pr-7002", "pull_request_name": "Too many", "author_id": "u30", "reviewers_count": 4}`
+	req = httptest.NewRequest(http.MethodPost, "/pullRequest/create", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w4 := httptest.NewRecorder()
+
+	env.PRHandler.CreatePR(w4, req)
+
+	if w4.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for reviewers_count above max, got %d: %s", w4.Code, w4.Body.String())
+	}
+}
+
+func TestCreatePRNotEnoughReviewers(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "docs", 3)
+
+	w := updateTestTeamSettings(t, env, `{"team_name": "docs", "min_reviewers": 3, "max_reviewers": 3}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w2 := CreateTestPR(t, env.PRHandler, "pr-7100", "Guide", "u30")
+
+	if w2.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w2.Code, w2.Body.String())
+	}
+
+	var errorResponse map[string]interface{}
+	if err := json.NewDecoder(w2.Body).Decode(&errorResponse); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+
+	errorObj := errorResponse["error"].(map[string]interface{})
+	if errorObj["code"] != "NOT_ENOUGH_REVIEWERS" {
+		t.Errorf("expected error code NOT_ENOUGH_REVIEWERS, got %v", errorObj["code"])
+	}
+}
+
+func TestUpdateTeamSettingsInvalidBounds(t *testing.T) {
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "docs", 3)
+
+	w := updateTestTeamSettings(t, env, `{"team_name": "docs", "min_reviewers": 3, "max_reviewers": 1}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jersonmade/pr-reviewer-service/internal/storage"
+	"github.com/Jersonmade/pr-reviewer-service/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spanExporterOnce sync.Once
+	spanExporter     *tracetest.InMemoryExporter
+)
+
+// recordSpans routes every span of the process to an in-memory exporter and
+// empties it. The global provider can only be installed once, since tracers
+// obtained earlier keep delegating to the first one.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	spanExporterOnce.Do(func() {
+		if _, err := tracing.Setup(context.Background(), tracing.ExporterOff, "", nil); err != nil {
+			t.Fatalf("failed to set up tracing: %v", err)
+		}
+
+		spanExporter = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter)))
+	})
+
+	spanExporter.Reset()
+
+	return spanExporter
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+
+	return nil
+}
+
+func spanAttr(span *tracetest.SpanStub, key string) attribute.Value {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+func TestTracingFollowsRequestIntoServicesAndStorage(t *testing.T) {
+	exporter := recordSpans(t)
+
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	CreateTestTeam(t, env.TeamHandler, "tracing", 3)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pullRequest/create", env.PRHandler.CreatePR)
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {})
+
+	handler := tracing.Middleware(mux, mux, "/livez")
+
+	exporter.Reset()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentID = "00f067aa0ba902b7"
+
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create",
+		bytes.NewBufferString(`{"pull_request_id": "pr-trace", "pull_request_name": "Tracing", "author_id": "u30"}`))
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	spans := exporter.GetSpans()
+
+	server := findSpan(spans, "POST /pullRequest/create")
+	if server == nil {
+		t.Fatalf("expected a span named after the route, got %d spans", len(spans))
+	}
+
+	if server.SpanContext.TraceID().String() != traceID || server.Parent.SpanID().String() != parentID {
+		t.Errorf("expected the incoming traceparent to be continued, got trace %s parent %s",
+			server.SpanContext.TraceID(), server.Parent.SpanID())
+	}
+
+	create := findSpan(spans, "PRService.CreatePR")
+	if create == nil || create.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Fatalf("expected PRService.CreatePR to be a child of the server span, got %+v", create)
+	}
+
+	if spanAttr(create, "pr.id").AsString() != "pr-trace" {
+		t.Errorf("expected the PR id on the service span, got %v", create.Attributes)
+	}
+
+	getUser := findSpan(spans, "UserService.GetUser")
+	if getUser == nil || getUser.Parent.SpanID() != create.SpanContext.SpanID() {
+		t.Errorf("expected UserService.GetUser to be a child of PRService.CreatePR, got %+v", getUser)
+	}
+
+	if findSpan(spans, "GET /livez") != nil {
+		t.Error("expected /livez not to be traced")
+	}
+
+	if _, ok := env.Store.(*storage.PostgresStorage); !ok {
+		return
+	}
+
+	statements := 0
+	for _, span := range spans {
+		if strings.HasPrefix(span.Name, "sql.") && span.SpanContext.TraceID().String() == traceID {
+			statements++
+		}
+	}
+
+	if statements == 0 {
+		t.Error("expected SQL statements to be traced within the request")
+	}
+}
+
+func TestTracingRecordsDomainErrors(t *testing.T) {
+	exporter := recordSpans(t)
+
+	env := SetupMemoryEnvironment(t)
+	defer env.Cleanup()
+
+	if w := CreateTestPR(t, env.PRHandler, "pr-trace", "Tracing", "nobody"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+
+	create := findSpan(exporter.GetSpans(), "PRService.CreatePR")
+	if create == nil {
+		t.Fatal("expected a PRService.CreatePR span")
+	}
+
+	if spanAttr(create, "error.code").AsString() != "NOT_FOUND" || create.Status.Code == codes.Error {
+		t.Errorf("expected a domain error to be tagged but not fail the span, got %v %v", create.Attributes, create.Status)
+	}
+}